@@ -3,7 +3,7 @@ module github.com/mstrehse/mcp-brain
 go 1.25.0
 
 require (
-	github.com/mark3labs/mcp-go v0.38.0
+	github.com/mark3labs/mcp-go v0.43.2
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -10,6 +10,7 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/mstrehse/mcp-brain/pkg/actions"
+	"github.com/mstrehse/mcp-brain/pkg/transport"
 )
 
 //go:embed brain-mcp-instructions.md
@@ -18,8 +19,19 @@ var serverInstructions string
 func main() {
 	// Define command line flags
 	brainDir := flag.String("brain-dir", "", "Directory to store brain data (defaults to ./.brain)")
+	transportName := flag.String("transport", transport.Stdio, "Transport to serve the MCP server over: stdio, http or sse")
+	listenAddr := flag.String("listen", transport.DefaultListenAddr, "Address the http and sse transports listen on")
+	authToken := flag.String("auth-token", os.Getenv("BRAIN_AUTH_TOKEN"), "Bearer token clients of the http and sse transports must send (defaults to $BRAIN_AUTH_TOKEN)")
+	askBackend := flag.String("ask", actions.AskBackendAuto, "How ask-question reaches the user: auto, dialog, tty, file, scripted or web")
+	askTimeout := flag.Duration("ask-timeout", actions.DefaultAskTimeout, "How long ask-question waits for an answer before taking the default, 0 waits forever")
+	askPath := flag.String("ask-path", "", "Terminal device for tty (defaults to /dev/tty), questions directory for file (defaults to <brain-dir>/questions), answers file for scripted or listen address for web (defaults to 127.0.0.1:8090)")
 	flag.Parse()
 
+	if *transportName != transport.Stdio && *transportName != transport.HTTP && *transportName != transport.SSE {
+		log.Fatalf("Unknown transport '%s', use stdio, http or sse\n", *transportName)
+		return
+	}
+
 	// Determine the base directory
 	var baseDir string
 	if *brainDir != "" {
@@ -53,6 +65,10 @@ func main() {
 		"Gives your LLM agent a brain and the ability to remember things",
 		"1.0.0",
 		server.WithToolCapabilities(true),
+		server.WithPromptCapabilities(true),
+		server.WithResourceCapabilities(false, true),
+		server.WithElicitation(),
+		server.WithPaginationLimit(100),
 		server.WithInstructions(serverInstructions),
 	)
 
@@ -100,11 +116,45 @@ func main() {
 
 	// Add ask-question tool
 	askQuestionTool := mcp.NewTool("ask-question",
-		mcp.WithDescription("Ask the user a question via a popup dialog. The user's answer is returned. Works on GNOME (Linux) and OSX. Always use the full functionality of this tool and its parameters."),
+		mcp.WithDescription("Ask the user a question, inline in the client when it supports elicitation and via a popup dialog otherwise. Offer 'choices' when there are multiple options (with 'multi_select' to allow picking several) or set 'confirm' for a yes/no question. The answer is returned as JSON: 'answer' for typed text or a single picked choice, 'selected' for picked choices and 'confirmed' for yes/no questions. Works on GNOME (Linux) and OSX, and headless through a terminal or answer files. Always use the full functionality of this tool and its parameters."),
 		mcp.WithString("question",
 			mcp.Required(),
 			mcp.Description("The question to ask the user."),
 		),
+		mcp.WithArray("choices",
+			mcp.Description("Options the user picks from instead of typing an answer."),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("multi_select",
+			mcp.Description("Allow picking several choices."),
+		),
+		mcp.WithString("default",
+			mcp.Description("Prefilled answer: text, one of the choices (comma-separated choices with multi_select), or yes/no for confirm questions."),
+		),
+		mcp.WithBoolean("confirm",
+			mcp.Description("Ask a yes/no question instead of a free text one."),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Seconds to wait for an answer (defaults to the server's --ask-timeout). When the time is up the default is returned with 'timed_out' set, or an error without a default."),
+		),
+		mcp.WithBoolean("reuse",
+			mcp.Description("Return the user's latest answer to the same question with 'reused' set instead of asking again, if there is one."),
+		),
+	)
+
+	// Add decisions-search tool
+	decisionsSearchTool := mcp.NewTool("decisions-search",
+		mcp.WithDescription("Search the answers the user gave to earlier 'ask-question' calls, across all sessions. Every question is logged with its choices, answer, time and session. CHECK FIRST: Search here before asking the user something they may already have decided, and follow earlier decisions unless the situation changed. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Keywords to search for in the questions, choices and answers."),
+		),
+		mcp.WithBoolean("current_session",
+			mcp.Description("Only search decisions made in the current chat session, or in this server process for stdio clients."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of results to return (defaults to 10)."),
+		),
 	)
 
 	// Add memories-list tool
@@ -116,17 +166,165 @@ func main() {
 		),
 	)
 
+	// Add memories-search tool
+	memoriesSearchTool := mcp.NewTool("memories-search",
+		mcp.WithDescription("Full-text search over all memories of a specific project. Returns the best matching markdown files ranked by relevance, with matching lines and their line numbers. Use this to quickly find existing knowledge about a topic instead of reading memories one by one, then use 'memory-get' to read the full content of relevant results. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("project",
+			mcp.Required(),
+			mcp.Description("The name of the project (usually the folder name) to search memories in."),
+		),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Keywords to search for."),
+		),
+		mcp.WithString("path_prefix",
+			mcp.Description("Only search memories whose path starts with this prefix, e.g. a subfolder like 'architecture/'."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of results to return (defaults to 10)."),
+		),
+	)
+
+	// Add memories-related tool
+	memoriesRelatedTool := mcp.NewTool("memories-related",
+		mcp.WithDescription("Find memories of a specific project that are similar to a free text or to an existing memory, even when they use different words. Works completely offline. Use this to discover related knowledge before storing something new and to follow connections between memories. Provide either 'query' or 'path'. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("project",
+			mcp.Required(),
+			mcp.Description("The name of the project (usually the folder name) to find related memories in."),
+		),
+		mcp.WithString("query",
+			mcp.Description("Free text describing the topic to find related memories for."),
+		),
+		mcp.WithString("path",
+			mcp.Description("Relative path of an existing memory to find related memories for. Do not use absolute paths or '..'."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of results to return (defaults to 5)."),
+		),
+	)
+
+	// Add projects-list tool
+	projectsListTool := mcp.NewTool("projects-list",
+		mcp.WithDescription("List all projects that have memories stored in the user's brain. Each project has its own isolated knowledge base. Use this to find the correct project name before working with memories, especially when the folder name is ambiguous. Always use the full functionality of this tool and its parameters."),
+	)
+
 	// Add task management tools
 	tasksAddTool := mcp.NewTool("tasks-add",
-		mcp.WithDescription("Add multiple tasks to the queue for the current chat session. WORKFLOW PATTERN: When facing complex work, immediately break it down into specific tasks using this tool. Create a complete task list upfront, then use 'task-get' to retrieve and complete them one by one. This ensures systematic completion and prevents missing important steps. This is mandatory - tasks should always be created for future work. Always use the full functionality of this tool and its parameters."),
+		mcp.WithDescription("Add multiple tasks to the queue for the current chat session. WORKFLOW PATTERN: When facing complex work, immediately break it down into specific tasks using this tool. Create a complete task list upfront, then use 'task-get' to retrieve and complete them one by one. This ensures systematic completion and prevents missing important steps. When steps can only start after others are done, use 'tasks' with 'depends_on' instead of 'contents'. Urgent work can jump ahead with a higher 'priority' or be inserted with 'front', 'after' or 'position'. When a task turns out to be too big, split it by passing its ID as 'parent_id'; the subtasks run before the rest of the queue and the parent is finished automatically once all of them are finished, and fails if one of them failed. This is mandatory - tasks should always be created for future work. Always use the full functionality of this tool and its parameters."),
 		mcp.WithArray("contents",
-			mcp.Required(),
 			mcp.Description("Array of task descriptions to add."),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("tasks",
+			mcp.Description("JSON array of tasks with dependencies, used instead of 'contents', e.g. [{\"content\": \"Run migrations\"}, {\"content\": \"Update fixtures\", \"depends_on\": [\"#1\"], \"priority\": 1}]. 'depends_on' takes IDs of queued tasks or '#n' for the n-th task of this call."),
+		),
+		mcp.WithString("parent_id",
+			mcp.Description("ID of an unfinished task to split into the given subtasks."),
+		),
+		mcp.WithNumber("priority",
+			mcp.Description("Priority of the tasks given in 'contents' (defaults to 0). Higher priorities are handed out first; entries of 'tasks' set their own 'priority'."),
+		),
+		mcp.WithBoolean("front",
+			mcp.Description("Insert the tasks at the front of the queue instead of appending them."),
+		),
+		mcp.WithString("after",
+			mcp.Description("Insert the tasks right after the task with this ID and its subtasks."),
+		),
+		mcp.WithNumber("position",
+			mcp.Description("Insert the tasks at this 1-based position of the queue, as shown by 'tasks-list'."),
 		),
 	)
 
 	taskGetTool := mcp.NewTool("task-get",
-		mcp.WithDescription("Retrieve and remove the next pending task from the queue for the current chat session. SYSTEMATIC WORKFLOW: After completing each task, immediately call this tool to get the next task. This ensures you work through your task list systematically and don't miss any steps. Continue calling this tool until you get 'no pending tasks' - only then is your work complete. This is mandatory - always check for remaining tasks before considering work complete. Always use the full functionality of this tool and its parameters."),
+		mcp.WithDescription("Retrieve the pending task with the highest priority whose dependencies are done from the queue for the current chat session and mark it as in progress. SYSTEMATIC WORKFLOW: Work on the returned task, then report its outcome with 'task-complete' or 'task-fail' using the task ID, and immediately call this tool again to get the next task. This ensures you work through your task list systematically and don't miss any steps. Continue calling this tool until you get 'no pending tasks' - only then is your work complete. This is mandatory - always check for remaining tasks before considering work complete. Always use the full functionality of this tool and its parameters."),
+	)
+
+	taskPeekTool := mcp.NewTool("task-peek",
+		mcp.WithDescription("Look at the next pending task without retrieving it or changing its status. Use this to check what comes next, e.g. while re-planning, without dequeuing the task. Always use the full functionality of this tool and its parameters."),
+	)
+
+	tasksListTool := mcp.NewTool("tasks-list",
+		mcp.WithDescription("List the tasks of the queue without consuming them, including their IDs, statuses and outcome notes. Use this to review progress, find interrupted tasks that are still in progress, see how tasks were split into subtasks, or re-plan the remaining work. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("status",
+			mcp.Description("Only list tasks with this status. 'blocked' lists pending tasks that wait for unfinished dependencies."),
+			mcp.Enum("pending", "blocked", "in_progress", "done", "failed", "cancelled"),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Number of tasks to skip (defaults to 0)."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of tasks to return (defaults to 50)."),
+		),
+		mcp.WithBoolean("tree",
+			mcp.Description("Nest subtasks below their parent tasks. Offset and limit then apply to the top-level tasks."),
+		),
+	)
+
+	taskMoveTool := mcp.NewTool("task-move",
+		mcp.WithDescription("Move a task together with its subtasks to another place in the queue, e.g. to pull an urgent fix ahead of queued refactoring. Use exactly one of 'front', 'after' or 'position'. Note that 'task-get' still prefers tasks with a higher priority. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("The ID of the task to move."),
+		),
+		mcp.WithBoolean("front",
+			mcp.Description("Move the task to the front of the queue."),
+		),
+		mcp.WithString("after",
+			mcp.Description("Move the task right after the task with this ID and its subtasks."),
+		),
+		mcp.WithNumber("position",
+			mcp.Description("Move the task to this 1-based position of the queue, as shown by 'tasks-list'."),
+		),
+	)
+
+	taskCompleteTool := mcp.NewTool("task-complete",
+		mcp.WithDescription("Mark a task as done after finishing it. MANDATORY: Call this for every task you retrieved with 'task-get' once the work is finished, so progress is recorded and survives interruptions. Add a short note describing the outcome. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("The ID of the task to complete."),
+		),
+		mcp.WithString("note",
+			mcp.Description("Short description of the outcome, e.g. what was changed."),
+		),
+	)
+
+	taskFailTool := mcp.NewTool("task-fail",
+		mcp.WithDescription("Mark a task as failed when it cannot be completed. Use this instead of silently skipping a task, and describe why it failed so the user or a later session can pick it up. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("The ID of the task that failed."),
+		),
+		mcp.WithString("note",
+			mcp.Required(),
+			mcp.Description("Why the task failed and what is needed to complete it."),
+		),
+	)
+
+	taskReleaseTool := mcp.NewTool("task-release",
+		mcp.WithDescription("Put an in-progress task back to pending, so 'task-get' hands it out again. Use this for tasks that were interrupted, e.g. because an earlier session crashed while working on them; find them with 'tasks-list' and status 'in_progress'. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("The ID of the in-progress task to release."),
+		),
+	)
+
+	taskQueuesListTool := mcp.NewTool("task-queues-list",
+		mcp.WithDescription("List the task queues with their number of pending and in-progress tasks, and show which queue the current chat session works on. Every session has its own queue, so parallel sessions on the same brain don't take each other's tasks. Always use the full functionality of this tool and its parameters."),
+	)
+
+	taskQueueSwitchTool := mcp.NewTool("task-queue-switch",
+		mcp.WithDescription("Switch the current chat session to another task queue, e.g. to continue the tasks of an earlier session or to keep separate work streams apart. All task tools of this session use the selected queue afterwards. The queue is created when tasks are added to it. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("queue",
+			mcp.Required(),
+			mcp.Description("Name of the queue to work on (letters, digits, '.', '_' and '-')."),
+		),
+	)
+
+	taskQueueArchiveTool := mcp.NewTool("task-queue-archive",
+		mcp.WithDescription("Archive a task queue once its work is finished. The queue disappears from the list and its name starts over with an empty queue, while the archived tasks are kept on disk for reference. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("queue",
+			mcp.Description("Name of the queue to archive (defaults to the queue of the current session)."),
+		),
 	)
 
 	// Add template management tools
@@ -140,10 +338,13 @@ func main() {
 			mcp.Required(),
 			mcp.Description("The ID of the template to retrieve."),
 		),
+		mcp.WithNumber("version",
+			mcp.Description("Retrieve this version of the template instead of the current one."),
+		),
 	)
 
 	taskTemplateCreateTool := mcp.NewTool("task-template-create",
-		mcp.WithDescription("Create a new reusable task template with parameters and task patterns. PATTERN CREATION: Use this tool to capture successful workflows as reusable templates. Define parameters using ${param} syntax in task descriptions for dynamic content. This builds institutional knowledge and accelerates future similar work. Always use the full functionality of this tool and its parameters."),
+		mcp.WithDescription("Create a new reusable task template with parameters and task patterns. PATTERN CREATION: Use this tool to capture successful workflows as reusable templates. Define parameters using ${param} syntax in task descriptions for dynamic content. Parameter types are string, enum, number, boolean and list (comma-separated); constrain them with 'values', 'min'/'max' (numbers), 'pattern' and 'min_length'/'max_length' (strings and lists). Defaults must satisfy these constraints and fill in missing values on instantiation. Every ${param} used in the tasks must be declared in 'parameters'. Tasks are strings, or objects like {\"content\": \"Review ${item}\", \"for_each\": \"services\"} or {\"content\": \"Check migrations\", \"when\": \"has_db\"} ('when' supports name, !name, name == value and name != value). Reuse common steps with {\"include\": \"<template_id>\", \"with\": {\"param\": \"${value}\"}} instead of copying them. Prerequisites are strings (notes) or checks like {\"type\": \"memory\", \"project\": \"p\", \"path\": \"architecture.md\"}, {\"type\": \"file\", \"path\": \"go.mod\"}, {\"type\": \"command\", \"command\": \"docker\"} or {\"type\": \"template\", \"template\": \"<template_id>\"}, verified before instantiation. This builds institutional knowledge and accelerates future similar work. Always use the full functionality of this tool and its parameters."),
 		mcp.WithString("template",
 			mcp.Required(),
 			mcp.Description("JSON representation of the task template structure."),
@@ -151,18 +352,24 @@ func main() {
 	)
 
 	taskTemplateInstantiateTool := mcp.NewTool("task-template-instantiate",
-		mcp.WithDescription("Create tasks from a template with specific parameters and add them to the current chat session. WORKFLOW ACCELERATION: Use this tool to quickly set up structured workflows from proven templates. The template parameters will be resolved and tasks added to your queue automatically. This is the preferred way to start complex work - templates over manual task creation. Always use the full functionality of this tool and its parameters."),
+		mcp.WithDescription("Create tasks from a template with specific parameters and add them to the current chat session. Prerequisites of the template are checked first; unmet ones are reported and block instantiation unless 'force' is set. WORKFLOW ACCELERATION: Use this tool to quickly set up structured workflows from proven templates. The template parameters will be resolved and tasks added to your queue automatically. This is the preferred way to start complex work - templates over manual task creation. Always use the full functionality of this tool and its parameters."),
 		mcp.WithString("template_id",
 			mcp.Required(),
 			mcp.Description("The ID of the template to instantiate."),
 		),
 		mcp.WithString("parameters",
-			mcp.Description("JSON object containing parameter values for the template."),
+			mcp.Description("JSON object containing parameter values for the template. Numbers, booleans and arrays (for list parameters) may be given as JSON values."),
+		),
+		mcp.WithNumber("version",
+			mcp.Description("Instantiate this version of the template instead of the current one. Included templates always use their current version."),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Instantiate the template even if some of its prerequisites are not met."),
 		),
 	)
 
 	taskTemplateUpdateTool := mcp.NewTool("task-template-update",
-		mcp.WithDescription("Update an existing task template with new parameters, tasks, or metadata. TEMPLATE MANAGEMENT: Use this tool to refine and improve existing templates based on experience. Always include the template ID in the template JSON to specify which template to update. This maintains template evolution and continuous improvement. Always use the full functionality of this tool and its parameters."),
+		mcp.WithDescription("Update an existing task template with new parameters, tasks, or metadata. TEMPLATE MANAGEMENT: Use this tool to refine and improve existing templates based on experience. Always include the template ID in the template JSON to specify which template to update. Every update is stored as a new version, so earlier versions can be inspected with 'task-template-history' and restored with 'task-template-rollback'. This maintains template evolution and continuous improvement. Always use the full functionality of this tool and its parameters."),
 		mcp.WithString("template",
 			mcp.Required(),
 			mcp.Description("JSON representation of the updated task template structure including the ID."),
@@ -170,33 +377,98 @@ func main() {
 	)
 
 	taskTemplateDeleteTool := mcp.NewTool("task-template-delete",
-		mcp.WithDescription("Delete a task template by ID. CAUTION: This permanently removes the template and cannot be undone. Use this tool to clean up obsolete or incorrect templates. Always verify the template ID before deletion. This helps maintain a clean template library. Always use the full functionality of this tool and its parameters."),
+		mcp.WithDescription("Delete a task template by ID. CAUTION: This permanently removes the template and cannot be undone. Use this tool to clean up obsolete or incorrect templates. Templates that are still included by other templates are only deleted with 'force'. Always verify the template ID before deletion. This helps maintain a clean template library. Always use the full functionality of this tool and its parameters."),
 		mcp.WithString("template_id",
 			mcp.Required(),
 			mcp.Description("The ID of the template to delete."),
 		),
+		mcp.WithBoolean("force",
+			mcp.Description("Delete the template even if other templates still include it."),
+		),
+	)
+
+	taskTemplateHistoryTool := mcp.NewTool("task-template-history",
+		mcp.WithDescription("List all versions of a task template with their version number, timestamp, name and number of tasks. VERSION INSPECTION: Use this tool to find the version to inspect with 'task-template-get' or to restore with 'task-template-rollback' after a bad update. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("template_id",
+			mcp.Required(),
+			mcp.Description("The ID of the template."),
+		),
+	)
+
+	taskTemplateRollbackTool := mcp.NewTool("task-template-rollback",
+		mcp.WithDescription("Restore a previous version of a task template. The restored content is stored as a new version, so no history is lost and the rollback can be undone. Use 'task-template-history' first to find the version to restore. Always use the full functionality of this tool and its parameters."),
+		mcp.WithString("template_id",
+			mcp.Required(),
+			mcp.Description("The ID of the template to roll back."),
+		),
+		mcp.WithNumber("version",
+			mcp.Required(),
+			mcp.Description("The version to restore."),
+		),
 	)
 
 	// Create actions with dependency injection
-	askQuestionAction := actions.NewAskQuestionAction()
+	askRepo, err := actions.NewAskRepository(*askBackend, *askPath, baseDir)
+	if err != nil {
+		log.Fatalf("Error initializing ask backend: %v\n", err)
+		return
+	}
+	askQuestionAction := actions.NewAskQuestionAction(askRepo, s, repositories.Decision, *askTimeout)
+	queues := actions.NewQueueSelector()
+
+	// Workspace file prerequisites are relative to the directory the server was started in
+	workspaceDir, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("Error getting current working directory: %v\n", err)
+		return
+	}
+	// Memories are also offered as resources, kept up to date on every change
+	knowledge := actions.NewKnowledgeResourceRepository(repositories.Knowledge, s)
+	if err := knowledge.RefreshResources(); err != nil {
+		log.Fatalf("Error registering memory resources: %v\n", err)
+		return
+	}
+
+	// Templates are also offered as prompts, kept up to date on every change
+	templates := actions.NewTemplatePromptRepository(repositories.Template, s)
+	if err := templates.RefreshPrompts(); err != nil {
+		log.Fatalf("Error registering template prompts: %v\n", err)
+		return
+	}
+	prerequisites := actions.NewPrerequisiteChecker(knowledge, templates, workspaceDir)
 
 	// Register tools with dependency-injected handlers
-	s.AddTool(memoryStoreTool, actions.NewMemoryStoreHandler(repositories.Knowledge))
-	s.AddTool(memoryGetTool, actions.NewMemoryGetHandler(repositories.Knowledge))
-	s.AddTool(memoryDeleteTool, actions.NewMemoryDeleteHandler(repositories.Knowledge))
+	s.AddTool(memoryStoreTool, actions.NewMemoryStoreHandler(knowledge))
+	s.AddTool(memoryGetTool, actions.NewMemoryGetHandler(knowledge))
+	s.AddTool(memoryDeleteTool, actions.NewMemoryDeleteHandler(knowledge))
 	s.AddTool(askQuestionTool, askQuestionAction.AskQuestion)
-	s.AddTool(memoriesListTool, actions.NewMemoriesListHandler(repositories.Knowledge))
-	s.AddTool(tasksAddTool, actions.NewTasksAddHandler(repositories.Task))
-	s.AddTool(taskGetTool, actions.NewTaskGetHandler(repositories.Task))
-	s.AddTool(taskTemplatesListTool, actions.NewTaskTemplatesListHandler(repositories.Template))
-	s.AddTool(taskTemplateGetTool, actions.NewTaskTemplateGetHandler(repositories.Template))
-	s.AddTool(taskTemplateCreateTool, actions.NewTaskTemplateCreateHandler(repositories.Template))
-	s.AddTool(taskTemplateInstantiateTool, actions.NewTaskTemplateInstantiateHandler(repositories.Template, repositories.Task))
-	s.AddTool(taskTemplateUpdateTool, actions.NewTaskTemplateUpdateHandler(repositories.Template))
-	s.AddTool(taskTemplateDeleteTool, actions.NewTaskTemplateDeleteHandler(repositories.Template))
-
-	// Start the stdio server
-	if err := server.ServeStdio(s); err != nil {
+	s.AddTool(decisionsSearchTool, actions.NewDecisionsSearchHandler(repositories.Decision))
+	s.AddTool(memoriesListTool, actions.NewMemoriesListHandler(knowledge))
+	s.AddTool(memoriesSearchTool, actions.NewMemoriesSearchHandler(knowledge))
+	s.AddTool(memoriesRelatedTool, actions.NewMemoriesRelatedHandler(knowledge))
+	s.AddTool(projectsListTool, actions.NewProjectsListHandler(knowledge))
+	s.AddTool(tasksAddTool, actions.NewTasksAddHandler(repositories.Task, queues))
+	s.AddTool(taskGetTool, actions.NewTaskGetHandler(repositories.Task, queues))
+	s.AddTool(taskPeekTool, actions.NewTaskPeekHandler(repositories.Task, queues))
+	s.AddTool(tasksListTool, actions.NewTasksListHandler(repositories.Task, queues))
+	s.AddTool(taskMoveTool, actions.NewTaskMoveHandler(repositories.Task, queues))
+	s.AddTool(taskCompleteTool, actions.NewTaskCompleteHandler(repositories.Task, queues))
+	s.AddTool(taskFailTool, actions.NewTaskFailHandler(repositories.Task, queues))
+	s.AddTool(taskReleaseTool, actions.NewTaskReleaseHandler(repositories.Task, queues))
+	s.AddTool(taskQueuesListTool, actions.NewTaskQueuesListHandler(repositories.Task, queues))
+	s.AddTool(taskQueueSwitchTool, actions.NewTaskQueueSwitchHandler(queues))
+	s.AddTool(taskQueueArchiveTool, actions.NewTaskQueueArchiveHandler(repositories.Task, queues))
+	s.AddTool(taskTemplatesListTool, actions.NewTaskTemplatesListHandler(templates))
+	s.AddTool(taskTemplateGetTool, actions.NewTaskTemplateGetHandler(templates))
+	s.AddTool(taskTemplateCreateTool, actions.NewTaskTemplateCreateHandler(templates))
+	s.AddTool(taskTemplateInstantiateTool, actions.NewTaskTemplateInstantiateHandler(templates, repositories.Task, queues, prerequisites))
+	s.AddTool(taskTemplateUpdateTool, actions.NewTaskTemplateUpdateHandler(templates))
+	s.AddTool(taskTemplateDeleteTool, actions.NewTaskTemplateDeleteHandler(templates))
+	s.AddTool(taskTemplateHistoryTool, actions.NewTaskTemplateHistoryHandler(templates))
+	s.AddTool(taskTemplateRollbackTool, actions.NewTaskTemplateRollbackHandler(templates))
+
+	// Start the server on the selected transport
+	if err := transport.Serve(s, *transportName, *listenAddr, *authToken); err != nil {
 		log.Fatalf("Server error: %v\n", err)
 	}
 }
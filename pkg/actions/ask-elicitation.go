@@ -0,0 +1,109 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// Elicitor sends elicitation requests to the client session of a request, as *server.MCPServer does
+type Elicitor interface {
+	RequestElicitation(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error)
+}
+
+// clientSupportsElicitation reports whether the client session of a request declared the elicitation capability
+func clientSupportsElicitation(ctx context.Context) bool {
+	session := server.ClientSessionFromContext(ctx)
+	if _, ok := session.(server.SessionWithElicitation); !ok {
+		return false
+	}
+	withInfo, ok := session.(server.SessionWithClientInfo)
+	return ok && withInfo.GetClientCapabilities().Elicitation != nil
+}
+
+// elicitationSchema returns the schema of the form the client shows for a question.
+// The property names match the JSON fields of contracts.Answer.
+func elicitationSchema(question contracts.Question) map[string]any {
+	var name string
+	var property map[string]any
+	switch {
+	case question.Confirm:
+		name = "confirmed"
+		property = map[string]any{"type": "boolean", "title": "Confirm"}
+		if confirmed, err := contracts.ParseConfirmation(question.Default); err == nil {
+			property["default"] = confirmed
+		}
+	case question.MultiSelect:
+		name = "selected"
+		property = map[string]any{
+			"type":        "array",
+			"title":       "Choices",
+			"items":       map[string]any{"type": "string", "enum": question.Choices},
+			"uniqueItems": true,
+		}
+		if defaults := question.DefaultChoices(); len(defaults) > 0 {
+			property["default"] = defaults
+		}
+	case len(question.Choices) > 0:
+		name = "answer"
+		property = map[string]any{"type": "string", "title": "Choice", "enum": question.Choices}
+		if question.Default != "" {
+			property["default"] = question.Default
+		}
+	default:
+		name = "answer"
+		property = map[string]any{"type": "string", "title": "Answer"}
+		if question.Default != "" {
+			property["default"] = question.Default
+		}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{name: property},
+		"required":   []string{name},
+	}
+}
+
+// parseElicitationContent turns the accepted form content into the answer of a question
+func parseElicitationContent(question contracts.Question, content any) (*contracts.Answer, error) {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	var form contracts.FormAnswer
+	if err := json.Unmarshal(data, &form); err != nil {
+		return nil, fmt.Errorf("unexpected form content: %w", err)
+	}
+	return contracts.ParseFormAnswer(question, form)
+}
+
+// elicit asks a question through the client of a request
+func elicit(ctx context.Context, elicitor Elicitor, question contracts.Question) (*contracts.Answer, error) {
+	result, err := elicitor.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message:         question.Text,
+			RequestedSchema: elicitationSchema(question),
+		},
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch result.Action {
+	case mcp.ElicitationResponseActionAccept:
+		return parseElicitationContent(question, result.Content)
+	case mcp.ElicitationResponseActionDecline:
+		return nil, contracts.ErrQuestionDeclined
+	case mcp.ElicitationResponseActionCancel:
+		return nil, contracts.ErrQuestionDismissed
+	}
+	return nil, fmt.Errorf("unknown elicitation action '%s'", result.Action)
+}
@@ -2,45 +2,217 @@ package actions
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mstrehse/mcp-brain/pkg/contracts"
 	"github.com/mstrehse/mcp-brain/pkg/repositories/ask/cli"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/ask/file"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/ask/scripted"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/ask/tty"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/ask/web"
+	"github.com/mstrehse/mcp-brain/pkg/transport"
 )
 
+// Ask backends selectable with the --ask flag
+const (
+	AskBackendAuto     = "auto"     // dialogs when a display is available, otherwise files
+	AskBackendDialog   = "dialog"   // zenity on Linux, osascript on OSX
+	AskBackendTTY      = "tty"      // prompt on a terminal device
+	AskBackendFile     = "file"     // question and answer files in a directory, also works with named pipes
+	AskBackendScripted = "scripted" // prepared answers from a file
+	AskBackendWeb      = "web"      // page served on localhost, for WSL, SSH sessions and desktops without zenity
+)
+
+// DefaultAskTimeout is how long ask-question waits for an answer unless configured otherwise
+const DefaultAskTimeout = 10 * time.Minute
+
+// AskQuestionAction asks through the client with elicitation when the client supports it,
+// and falls back to the ask backend otherwise
 type AskQuestionAction struct {
 	AskRepository contracts.AskRepository
+	Elicitor      Elicitor
+
+	// Decisions records every answer and provides earlier answers for reuse, nil disables the log
+	Decisions contracts.DecisionRepository
+
+	// Timeout is how long to wait for an answer when the question sets no timeout, 0 waits forever
+	Timeout time.Duration
 }
 
-func NewAskQuestionAction() *AskQuestionAction {
-	var askRepo contracts.AskRepository
+func NewAskQuestionAction(askRepo contracts.AskRepository, elicitor Elicitor, decisions contracts.DecisionRepository, timeout time.Duration) *AskQuestionAction {
+	return &AskQuestionAction{
+		AskRepository: askRepo,
+		Elicitor:      elicitor,
+		Decisions:     decisions,
+		Timeout:       timeout,
+	}
+}
+
+// NewAskRepository creates the ask backend. The path is the terminal device for tty, the questions
+// directory for file (defaults to <baseDir>/questions), the answers file for scripted and the listen address for web.
+func NewAskRepository(backend string, path string, baseDir string) (contracts.AskRepository, error) {
+	switch backend {
+	case AskBackendAuto, "":
+		if dialog := newDialogRepository(); dialog != nil {
+			return dialog, nil
+		}
+		log.Printf("No dialogs available, answer questions through files in %s\n", questionsDir(path, baseDir))
+		return file.NewRepository(questionsDir(path, baseDir))
+	case AskBackendDialog:
+		if dialog := newDialogRepository(); dialog != nil {
+			return dialog, nil
+		}
+		return nil, fmt.Errorf("dialogs need zenity and a display on Linux, or OSX")
+	case AskBackendTTY:
+		return tty.NewRepository(path)
+	case AskBackendFile:
+		return file.NewRepository(questionsDir(path, baseDir))
+	case AskBackendScripted:
+		if path == "" {
+			return nil, fmt.Errorf("the scripted ask backend needs an answers file")
+		}
+		return scripted.NewRepository(path)
+	case AskBackendWeb:
+		return newWebRepository(path)
+	}
+	return nil, fmt.Errorf("unknown ask backend '%s', use auto, dialog, tty, file, scripted or web", backend)
+}
+
+// newDialogRepository returns the dialog backend of the OS, or nil if it can't show dialogs
+func newDialogRepository() contracts.AskRepository {
 	switch runtime.GOOS {
 	case "linux":
-		askRepo = &cli.LinuxRepository{}
+		if _, err := exec.LookPath("zenity"); err != nil {
+			return nil
+		}
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return nil
+		}
+		return &cli.LinuxRepository{}
 	case "darwin":
-		askRepo = &cli.OsxRepository{}
+		return &cli.OsxRepository{}
 	}
+	return nil
+}
 
-	return &AskQuestionAction{
-		AskRepository: askRepo,
+// newWebRepository serves the questions page on a loopback address, as the page has no authentication
+func newWebRepository(addr string) (contracts.AskRepository, error) {
+	if addr == "" {
+		addr = web.DefaultListenAddr
 	}
+	if !transport.IsLoopback(addr) {
+		return nil, fmt.Errorf("the web ask backend only listens on loopback addresses, not %s", addr)
+	}
+
+	repo := web.NewRepository()
+	listening, err := repo.Listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Answer questions at http://%s/\n", listening)
+	return repo, nil
 }
 
-func (a *AskQuestionAction) AskQuestion(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if a.AskRepository == nil {
-		return mcp.NewToolResultError("Unsupported OS"), nil
+// questionsDir returns the directory of the file backend
+func questionsDir(path string, baseDir string) string {
+	if path != "" {
+		return path
 	}
+	return filepath.Join(baseDir, "questions")
+}
 
-	question, err := request.RequireString("question")
+func (a *AskQuestionAction) AskQuestion(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	text, err := request.RequireString("question")
 	if err != nil {
 		return mcp.NewToolResultError("Missing 'question' parameter: " + err.Error()), nil
 	}
 
-	response, err := a.AskRepository.Ask(question)
-	if err != nil {
+	question := contracts.Question{
+		Text:        text,
+		Choices:     request.GetStringSlice("choices", nil),
+		MultiSelect: request.GetBool("multi_select", false),
+		Default:     request.GetString("default", ""),
+		Confirm:     request.GetBool("confirm", false),
+	}
+	if err := question.Validate(); err != nil {
+		return mcp.NewToolResultError("Invalid question: " + err.Error()), nil
+	}
+
+	if request.GetBool("reuse", false) && a.Decisions != nil {
+		previous, err := a.Decisions.Find(question)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to look up earlier decisions: " + err.Error()), nil
+		}
+		if previous != nil {
+			answer := *previous.Answer
+			answer.Reused = true
+			return answerResult(&answer)
+		}
+	}
+
+	useElicitation := a.Elicitor != nil && clientSupportsElicitation(ctx)
+	if !useElicitation && a.AskRepository == nil {
+		return mcp.NewToolResultError("No ask backend configured"), nil
+	}
+
+	timeout := a.Timeout
+	if seconds := request.GetFloat("timeout", 0); seconds > 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	askCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		askCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var answer *contracts.Answer
+	if useElicitation {
+		answer, err = elicit(askCtx, a.Elicitor, question)
+	} else {
+		answer, err = a.AskRepository.Ask(askCtx, question)
+	}
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return mcp.NewToolResultError("The question was cancelled before the user answered"), nil
+	case errors.Is(err, context.DeadlineExceeded):
+		if answer = question.DefaultAnswer(); answer == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("The user didn't answer within %s", timeout)), nil
+		}
+	case errors.Is(err, contracts.ErrQuestionDismissed), errors.Is(err, contracts.ErrQuestionDeclined):
+		return mcp.NewToolResultError("No answer: " + err.Error()), nil
+	case useElicitation:
+		return mcp.NewToolResultError("Failed to ask through the client: " + err.Error()), nil
+	default:
 		return mcp.NewToolResultError("Failed to show dialog: " + err.Error()), nil
 	}
 
-	return mcp.NewToolResultText(response), nil
+	if a.Decisions != nil {
+		decision := &contracts.Decision{Question: question, Answer: answer, Session: sessionID(ctx), AskedAt: time.Now()}
+		if _, err := a.Decisions.Record(decision); err != nil {
+			log.Printf("Failed to record decision: %v\n", err)
+		}
+	}
+
+	return answerResult(answer)
+}
+
+// answerResult returns an answer as tool result
+func answerResult(answer *contracts.Answer) (*mcp.CallToolResult, error) {
+	data, err := json.Marshal(answer)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
 }
@@ -0,0 +1,378 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/ask/file"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/decision"
+)
+
+// fakeAskRepository records the asked question and returns a fixed answer or error.
+// Without either it waits until the question is given up.
+type fakeAskRepository struct {
+	asked  *contracts.Question
+	answer *contracts.Answer
+	err    error
+}
+
+func (r *fakeAskRepository) Ask(ctx context.Context, question contracts.Question) (*contracts.Answer, error) {
+	r.asked = &question
+	if r.answer == nil && r.err == nil {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return r.answer, r.err
+}
+
+func TestAskQuestion(t *testing.T) {
+	callContext := func(t *testing.T, ctx context.Context, repo contracts.AskRepository, arguments map[string]interface{}) (*mcp.CallToolResult, string) {
+		action := &AskQuestionAction{AskRepository: repo}
+		result, err := action.AskQuestion(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		return result, textContent.Text
+	}
+	call := func(t *testing.T, repo contracts.AskRepository, arguments map[string]interface{}) (*mcp.CallToolResult, string) {
+		return callContext(t, context.Background(), repo, arguments)
+	}
+
+	t.Run("free text answers are structured", func(t *testing.T) {
+		repo := &fakeAskRepository{answer: &contracts.Answer{Text: "Postgres"}}
+		result, text := call(t, repo, map[string]interface{}{"question": "Which database?", "default": "SQLite"})
+		if result.IsError || text != `{"answer":"Postgres"}` {
+			t.Errorf("Expected structured answer, got: %s", text)
+		}
+		if repo.asked.Default != "SQLite" {
+			t.Errorf("Expected the default to be passed on, got %+v", repo.asked)
+		}
+	})
+
+	t.Run("choices are passed to the dialog", func(t *testing.T) {
+		repo := &fakeAskRepository{answer: &contracts.Answer{Selected: []string{"api", "web"}}}
+		result, text := call(t, repo, map[string]interface{}{
+			"question":     "Which services?",
+			"choices":      []interface{}{"api", "web", "worker"},
+			"multi_select": true,
+			"default":      "api, web",
+		})
+		if result.IsError {
+			t.Fatalf("Expected success, got: %s", text)
+		}
+		var answer contracts.Answer
+		if err := json.Unmarshal([]byte(text), &answer); err != nil {
+			t.Fatalf("Failed to unmarshal answer: %v", err)
+		}
+		if len(answer.Selected) != 2 || len(repo.asked.Choices) != 3 || !repo.asked.MultiSelect {
+			t.Errorf("Expected choices and selection, got question %+v and answer %s", repo.asked, text)
+		}
+	})
+
+	t.Run("confirm answers", func(t *testing.T) {
+		repo := &fakeAskRepository{answer: contracts.NewConfirmAnswer(false)}
+		_, text := call(t, repo, map[string]interface{}{"question": "Deploy now?", "confirm": true, "default": "no"})
+		if text != `{"confirmed":false}` {
+			t.Errorf("Expected a declined confirmation, got: %s", text)
+		}
+	})
+
+	t.Run("timed out questions take the default", func(t *testing.T) {
+		result, text := call(t, &fakeAskRepository{}, map[string]interface{}{"question": "Deploy now?", "confirm": true, "default": "no", "timeout": 0.01})
+		if result.IsError || text != `{"confirmed":false,"timed_out":true}` {
+			t.Errorf("Expected the default after the timeout, got: %s", text)
+		}
+
+		result, text = call(t, &fakeAskRepository{}, map[string]interface{}{"question": "Which database?", "timeout": 0.01})
+		if !result.IsError || !strings.Contains(text, "didn't answer within") {
+			t.Errorf("Expected a timeout without a default, got: %s", text)
+		}
+	})
+
+	t.Run("cancelled requests", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		result, text := callContext(t, ctx, &fakeAskRepository{}, map[string]interface{}{"question": "Which database?", "default": "SQLite"})
+		if !result.IsError || !strings.Contains(text, "cancelled") {
+			t.Errorf("Expected the question to be cancelled, got: %s", text)
+		}
+	})
+
+	t.Run("dismissed dialogs", func(t *testing.T) {
+		result, text := call(t, &fakeAskRepository{err: contracts.ErrQuestionDismissed}, map[string]interface{}{"question": "Which database?", "default": "SQLite"})
+		if !result.IsError || !strings.Contains(text, "dismissed") {
+			t.Errorf("Expected the question to be dismissed, got: %s", text)
+		}
+	})
+
+	t.Run("invalid questions are rejected before asking", func(t *testing.T) {
+		for name, arguments := range map[string]map[string]interface{}{
+			"confirm with choices":   {"question": "Sure?", "confirm": true, "choices": []interface{}{"a"}},
+			"multi without choices":  {"question": "Which?", "multi_select": true},
+			"default not a choice":   {"question": "Which?", "choices": []interface{}{"a", "b"}, "default": "c"},
+			"confirm default":        {"question": "Sure?", "confirm": true, "default": "maybe"},
+			"multi default unlisted": {"question": "Which?", "choices": []interface{}{"a", "b"}, "multi_select": true, "default": "a, c"},
+		} {
+			repo := &fakeAskRepository{}
+			result, text := call(t, repo, arguments)
+			if !result.IsError || !strings.Contains(text, "Invalid question") || repo.asked != nil {
+				t.Errorf("%s: expected rejection without a dialog, got: %s", name, text)
+			}
+		}
+	})
+}
+
+// elicitationSession is a client session that answers elicitation requests with a fixed result
+type elicitationSession struct {
+	testSession
+	capabilities mcp.ClientCapabilities
+	request      *mcp.ElicitationRequest
+	result       *mcp.ElicitationResult
+}
+
+func (s *elicitationSession) GetClientInfo() mcp.Implementation              { return mcp.Implementation{} }
+func (s *elicitationSession) SetClientInfo(mcp.Implementation)               {}
+func (s *elicitationSession) GetClientCapabilities() mcp.ClientCapabilities  { return s.capabilities }
+func (s *elicitationSession) SetClientCapabilities(c mcp.ClientCapabilities) { s.capabilities = c }
+func (s *elicitationSession) RequestElicitation(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	s.request = &request
+	return s.result, nil
+}
+
+func TestAskQuestionElicitation(t *testing.T) {
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithElicitation())
+
+	call := func(t *testing.T, session *elicitationSession, repo contracts.AskRepository, arguments map[string]interface{}) (*mcp.CallToolResult, string) {
+		action := NewAskQuestionAction(repo, mcpServer, nil, 0)
+		ctx := mcpServer.WithContext(context.Background(), session)
+		result, err := action.AskQuestion(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		return result, textContent.Text
+	}
+	accept := func(content map[string]any) *mcp.ElicitationResult {
+		return &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionAccept, Content: content}}
+	}
+	capable := mcp.ClientCapabilities{Elicitation: &struct{}{}}
+
+	t.Run("capable clients are asked inline", func(t *testing.T) {
+		session := &elicitationSession{capabilities: capable, result: accept(map[string]any{"answer": "Postgres"})}
+		repo := &fakeAskRepository{answer: &contracts.Answer{Text: "SQLite"}}
+		result, text := call(t, session, repo, map[string]interface{}{"question": "Which database?", "default": "MySQL"})
+		if result.IsError || text != `{"answer":"Postgres"}` {
+			t.Errorf("Expected the elicited answer, got: %s", text)
+		}
+		if repo.asked != nil || session.request == nil || session.request.Params.Message != "Which database?" {
+			t.Fatalf("Expected an elicitation instead of a dialog, got request %+v", session.request)
+		}
+		schema := session.request.Params.RequestedSchema.(map[string]any)
+		property := schema["properties"].(map[string]any)["answer"].(map[string]any)
+		if property["type"] != "string" || property["default"] != "MySQL" {
+			t.Errorf("Expected a string answer with default, got %+v", property)
+		}
+	})
+
+	t.Run("choices are offered as enum", func(t *testing.T) {
+		session := &elicitationSession{capabilities: capable, result: accept(map[string]any{"selected": []any{"api", "web"}})}
+		_, text := call(t, session, nil, map[string]interface{}{
+			"question":     "Which services?",
+			"choices":      []interface{}{"api", "web", "worker"},
+			"multi_select": true,
+		})
+		if text != `{"selected":["api","web"]}` {
+			t.Errorf("Expected the picked choices, got: %s", text)
+		}
+		schema := session.request.Params.RequestedSchema.(map[string]any)
+		property := schema["properties"].(map[string]any)["selected"].(map[string]any)
+		items := property["items"].(map[string]any)
+		if property["type"] != "array" || len(items["enum"].([]string)) != 3 {
+			t.Errorf("Expected an array of choices, got %+v", property)
+		}
+	})
+
+	t.Run("choices outside the enum are rejected", func(t *testing.T) {
+		session := &elicitationSession{capabilities: capable, result: accept(map[string]any{"answer": "mongo"})}
+		result, _ := call(t, session, nil, map[string]interface{}{"question": "Which?", "choices": []interface{}{"a", "b"}})
+		if !result.IsError {
+			t.Error("Expected an answer that is not a choice to be rejected")
+		}
+	})
+
+	t.Run("confirm questions", func(t *testing.T) {
+		session := &elicitationSession{capabilities: capable, result: accept(map[string]any{"confirmed": true})}
+		_, text := call(t, session, nil, map[string]interface{}{"question": "Deploy now?", "confirm": true, "default": "no"})
+		if text != `{"confirmed":true}` {
+			t.Errorf("Expected a confirmation, got: %s", text)
+		}
+		schema := session.request.Params.RequestedSchema.(map[string]any)
+		property := schema["properties"].(map[string]any)["confirmed"].(map[string]any)
+		if property["type"] != "boolean" || property["default"] != false {
+			t.Errorf("Expected a boolean with default, got %+v", property)
+		}
+	})
+
+	t.Run("declined questions", func(t *testing.T) {
+		session := &elicitationSession{capabilities: capable, result: &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionDecline}}}
+		result, text := call(t, session, nil, map[string]interface{}{"question": "Which database?"})
+		if !result.IsError || !strings.Contains(text, "declined") {
+			t.Errorf("Expected the question to be declined, got: %s", text)
+		}
+	})
+
+	t.Run("clients without the capability get a dialog", func(t *testing.T) {
+		session := &elicitationSession{result: accept(map[string]any{"answer": "Postgres"})}
+		repo := &fakeAskRepository{answer: &contracts.Answer{Text: "SQLite"}}
+		_, text := call(t, session, repo, map[string]interface{}{"question": "Which database?"})
+		if text != `{"answer":"SQLite"}` || session.request != nil || repo.asked == nil {
+			t.Errorf("Expected the dialog backend, got: %s", text)
+		}
+	})
+}
+
+func TestAskQuestionDecisions(t *testing.T) {
+	decisions, err := decision.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create decision repository: %v", err)
+	}
+
+	call := func(t *testing.T, repo contracts.AskRepository, arguments map[string]interface{}) (*mcp.CallToolResult, string) {
+		action := NewAskQuestionAction(repo, nil, decisions, 0)
+		result, err := action.AskQuestion(sessionContext("session-1"), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		return result, textContent.Text
+	}
+	question := map[string]interface{}{"question": "Which database?", "choices": []interface{}{"SQLite", "Postgres"}, "reuse": true}
+
+	repo := &fakeAskRepository{answer: &contracts.Answer{Text: "Postgres", Selected: []string{"Postgres"}}}
+	if _, text := call(t, repo, question); text != `{"answer":"Postgres","selected":["Postgres"]}` || repo.asked == nil {
+		t.Fatalf("Expected the user to be asked the first time, got: %s", text)
+	}
+
+	results, err := decisions.Search(contracts.DecisionQuery{Query: "database"})
+	if err != nil || len(results) != 1 || results[0].Session != "session-1" || results[0].Answer.Text != "Postgres" {
+		t.Fatalf("Expected the answer to be logged with its session, got %+v (%v)", results, err)
+	}
+
+	repo = &fakeAskRepository{answer: &contracts.Answer{Text: "SQLite", Selected: []string{"SQLite"}}}
+	if _, text := call(t, repo, question); text != `{"answer":"Postgres","selected":["Postgres"],"reused":true}` || repo.asked != nil {
+		t.Errorf("Expected the earlier answer to be reused, got: %s", text)
+	}
+
+	question["reuse"] = false
+	if _, text := call(t, repo, question); text != `{"answer":"SQLite","selected":["SQLite"]}` || repo.asked == nil {
+		t.Errorf("Expected the user to be asked without reuse, got: %s", text)
+	}
+
+	handler := NewDecisionsSearchHandler(decisions)
+	result, err := handler(sessionContext("session-2"), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{"query": "database", "current_session": true}}})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	textContent, _ := mcp.AsTextContent(result.Content[0])
+	if result.IsError || !strings.Contains(textContent.Text, `"count":0`) {
+		t.Errorf("Expected no decisions of another session, got: %s", textContent.Text)
+	}
+}
+
+func TestDecisionsSearchStdioSessions(t *testing.T) {
+	decisions, err := decision.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create decision repository: %v", err)
+	}
+
+	// Every stdio process reports the session ID "stdio", so the decision of
+	// another process must not count as one of this process
+	question := contracts.Question{Text: "Which database?", Choices: []string{"SQLite", "Postgres"}}
+	if _, err := decisions.Record(&contracts.Decision{Question: question, Answer: &contracts.Answer{Text: "SQLite"}, Session: newProcessSessionID()}); err != nil {
+		t.Fatalf("Failed to record decision: %v", err)
+	}
+
+	action := NewAskQuestionAction(&fakeAskRepository{answer: &contracts.Answer{Text: "Postgres"}}, nil, decisions, 0)
+	stdio := sessionContext("stdio")
+	if _, err := action.AskQuestion(stdio, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{"question": "Which database?", "choices": []interface{}{"SQLite", "Postgres"}}}}); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	handler := NewDecisionsSearchHandler(decisions)
+	result, err := handler(stdio, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{"query": "database", "current_session": true}}})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	textContent, _ := mcp.AsTextContent(result.Content[0])
+	var found struct {
+		Results []contracts.DecisionResult `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(textContent.Text), &found); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(found.Results) != 1 || found.Results[0].Answer.Text != "Postgres" || found.Results[0].Session != processSessionID {
+		t.Errorf("Expected only the decision of this process, got: %s", textContent.Text)
+	}
+}
+
+func TestNewAskRepository(t *testing.T) {
+	baseDir := t.TempDir()
+
+	if _, err := NewAskRepository("carrier-pigeon", "", baseDir); err == nil {
+		t.Error("Expected unknown backends to be rejected")
+	}
+	if _, err := NewAskRepository(AskBackendScripted, "", baseDir); err == nil {
+		t.Error("Expected the scripted backend to need an answers file")
+	}
+	if _, err := NewAskRepository(AskBackendWeb, "0.0.0.0:0", baseDir); err == nil {
+		t.Error("Expected the web backend to refuse addresses reachable from other machines")
+	}
+	if repo, err := NewAskRepository(AskBackendWeb, "127.0.0.1:0", baseDir); err != nil || repo == nil {
+		t.Errorf("Expected the web backend on a loopback address, got %v", err)
+	}
+
+	repo, err := NewAskRepository(AskBackendFile, "", baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create file backend: %v", err)
+	}
+	if repo == nil {
+		t.Fatal("Expected a file backend")
+	}
+	if info, err := os.Stat(filepath.Join(baseDir, "questions")); err != nil || !info.IsDir() {
+		t.Errorf("Expected the questions directory below the brain directory, got %v", err)
+	}
+
+	// Without a display the automatic selection falls back to answer files
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	if runtime.GOOS == "linux" {
+		repo, err := NewAskRepository(AskBackendAuto, "", baseDir)
+		if err != nil {
+			t.Fatalf("Failed to create automatic backend: %v", err)
+		}
+		if _, ok := repo.(*file.Repository); !ok {
+			t.Errorf("Expected the file backend as fallback, got %T", repo)
+		}
+		if _, err := NewAskRepository(AskBackendDialog, "", baseDir); err == nil {
+			t.Error("Expected dialogs to be unavailable without a display")
+		}
+	}
+}
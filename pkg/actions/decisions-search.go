@@ -0,0 +1,44 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewDecisionsSearchHandler creates a handler for searching the answers the user gave to earlier questions
+func NewDecisionsSearchHandler(repo contracts.DecisionRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, err := request.RequireString("query")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'query' parameter: " + err.Error()), nil
+		}
+
+		session := ""
+		if request.GetBool("current_session", false) {
+			session = sessionID(ctx)
+		}
+
+		results, err := repo.Search(contracts.DecisionQuery{
+			Query:   query,
+			Session: session,
+			Limit:   request.GetInt("limit", 0),
+		})
+		if err != nil {
+			return mcp.NewToolResultError("Failed to search decisions: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"results": results,
+			"count":   len(results),
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -0,0 +1,23 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// knowledgeErrorResult turns an error of the knowledge repository into a tool error.
+// Rejected paths get an explanation the model can act on instead of a raw error.
+func knowledgeErrorResult(message string, err error) *mcp.CallToolResult {
+	var pathErr *contracts.InvalidPathError
+	if errors.As(err, &pathErr) {
+		if errors.Is(pathErr.Err, contracts.ErrInvalidProject) {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid project '%s': %v. Use the plain folder name of the project.", pathErr.Path, pathErr.Err))
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid path '%s': %v. Use a relative path inside the project without '..', hidden or reserved names.", pathErr.Path, pathErr.Err))
+	}
+
+	return mcp.NewToolResultError(message + ": " + err.Error())
+}
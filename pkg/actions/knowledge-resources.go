@@ -0,0 +1,235 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// MemoryURITemplate is the resource template memories can be read through
+const MemoryURITemplate = "brain://{project}/{+path}"
+
+// memoryMIMEType is the MIME type of memory resources
+const memoryMIMEType = "text/markdown"
+
+// KnowledgeResourceRepository wraps a knowledge repository and keeps an MCP resource registered for every memory.
+// Storing or deleting memories through it updates the resources, notifying clients with
+// resources/list_changed when memories appear or disappear and resources/updated for the changed memory.
+type KnowledgeResourceRepository struct {
+	contracts.KnowledgeRepository
+	server *server.MCPServer
+
+	registered map[string]bool
+	mutex      sync.Mutex
+}
+
+// NewKnowledgeResourceRepository creates a repository that mirrors the memories of repo as resources of s
+func NewKnowledgeResourceRepository(repo contracts.KnowledgeRepository, s *server.MCPServer) *KnowledgeResourceRepository {
+	return &KnowledgeResourceRepository{
+		KnowledgeRepository: repo,
+		server:              s,
+		registered:          make(map[string]bool),
+	}
+}
+
+// MemoryURI returns the resource URI of a memory, brain://<project>/<path> with the .md extension.
+// The project and every path segment are escaped, so names with spaces, '#' or '?' stay part of the path.
+func MemoryURI(project string, memoryPath string) string {
+	segments := strings.Split(resourcePath(memoryPath), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf("brain://%s/%s", url.PathEscape(project), strings.Join(segments, "/"))
+}
+
+// resourcePath normalizes the path of a memory to the slash separated path with the .md extension
+func resourcePath(memoryPath string) string {
+	memoryPath = strings.Trim(path.Clean("/"+strings.ReplaceAll(memoryPath, `\`, "/")), "/")
+	if !strings.HasSuffix(memoryPath, ".md") {
+		memoryPath += ".md"
+	}
+	return memoryPath
+}
+
+// Write stores a memory and registers or updates its resource
+func (r *KnowledgeResourceRepository) Write(project string, memoryPath string, content string) error {
+	if err := r.KnowledgeRepository.Write(project, memoryPath, content); err != nil {
+		return err
+	}
+
+	uri := MemoryURI(project, memoryPath)
+	r.mutex.Lock()
+	isNew := !r.registered[uri]
+	r.registered[uri] = true
+	r.mutex.Unlock()
+
+	if isNew {
+		r.server.AddResource(r.memoryResource(project, memoryPath, uri), r.readResource)
+	}
+	r.notifyUpdated(uri)
+	return nil
+}
+
+// Delete removes a memory and its resource
+func (r *KnowledgeResourceRepository) Delete(project string, memoryPath string) error {
+	if err := r.KnowledgeRepository.Delete(project, memoryPath); err != nil {
+		return err
+	}
+
+	uri := MemoryURI(project, memoryPath)
+	r.mutex.Lock()
+	delete(r.registered, uri)
+	r.mutex.Unlock()
+
+	r.server.DeleteResources(uri)
+	r.notifyUpdated(uri)
+	return nil
+}
+
+// RefreshResources replaces the resources of the server with one resource per stored memory
+// and registers the resource template for looking up memories by URI
+func (r *KnowledgeResourceRepository) RefreshResources() error {
+	projects, err := r.ListProjects()
+	if err != nil {
+		return fmt.Errorf("failed to list projects for resources: %w", err)
+	}
+
+	registered := make(map[string]bool)
+	resources := []server.ServerResource{}
+	for _, project := range projects {
+		structure, err := r.List(project)
+		if err != nil {
+			return fmt.Errorf("failed to list memories of project %s for resources: %w", project, err)
+		}
+		for _, memoryPath := range memoryPaths(structure, "") {
+			uri := MemoryURI(project, memoryPath)
+			registered[uri] = true
+			resources = append(resources, server.ServerResource{
+				Resource: r.memoryResource(project, memoryPath, uri),
+				Handler:  r.readResource,
+			})
+		}
+	}
+
+	r.mutex.Lock()
+	r.registered = registered
+	r.mutex.Unlock()
+
+	r.server.SetResources(resources...)
+	r.server.AddResourceTemplate(
+		mcp.NewResourceTemplate(MemoryURITemplate, "memory",
+			mcp.WithTemplateDescription("A memory of a project in the user's brain, by project name and path"),
+			mcp.WithTemplateMIMEType(memoryMIMEType),
+		),
+		r.readResourceTemplate,
+	)
+
+	return nil
+}
+
+// memoryResource describes a memory as a resource. The name is unique, so resource lists page reliably.
+func (r *KnowledgeResourceRepository) memoryResource(project string, memoryPath string, uri string) mcp.Resource {
+	memoryPath = resourcePath(memoryPath)
+	return mcp.NewResource(uri, project+"/"+memoryPath,
+		mcp.WithResourceDescription(fmt.Sprintf("Memory '%s' of project '%s'", memoryPath, project)),
+		mcp.WithMIMEType(memoryMIMEType),
+	)
+}
+
+// readResource reads a registered memory resource
+func (r *KnowledgeResourceRepository) readResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	project, memoryPath, err := parseMemoryURI(request.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	return r.readMemory(request.Params.URI, project, memoryPath)
+}
+
+// readResourceTemplate reads a memory looked up through the resource template
+func (r *KnowledgeResourceRepository) readResourceTemplate(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	project := templateArgument(request.Params.Arguments, "project")
+	memoryPath := templateArgument(request.Params.Arguments, "path")
+	if project == "" || memoryPath == "" {
+		return nil, fmt.Errorf("invalid memory URI '%s': use %s", request.Params.URI, MemoryURITemplate)
+	}
+	return r.readMemory(request.Params.URI, project, memoryPath)
+}
+
+// templateArgument returns a variable matched by a resource template, which holds the matched values as a list
+func templateArgument(arguments map[string]any, name string) string {
+	switch value := arguments[name].(type) {
+	case string:
+		return value
+	case []string:
+		return strings.Join(value, "")
+	}
+	return ""
+}
+
+// readMemory returns the content of a memory as resource contents
+func (r *KnowledgeResourceRepository) readMemory(uri string, project string, memoryPath string) ([]mcp.ResourceContents, error) {
+	content, err := r.Read(project, memoryPath)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: memoryMIMEType,
+			Text:     content,
+		},
+	}, nil
+}
+
+// notifyUpdated tells all clients that the content of a memory resource changed.
+// The server has no subscriptions, so every initialized session is notified.
+func (r *KnowledgeResourceRepository) notifyUpdated(uri string) {
+	r.server.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+}
+
+// parseMemoryURI splits a brain://<project>/<path> URI into the unescaped project and path
+func parseMemoryURI(uri string) (string, string, error) {
+	invalid := fmt.Errorf("invalid memory URI '%s': use brain://<project>/<path>", uri)
+
+	project, memoryPath, found := strings.Cut(strings.TrimPrefix(uri, "brain://"), "/")
+	if !strings.HasPrefix(uri, "brain://") || !found || project == "" || memoryPath == "" {
+		return "", "", invalid
+	}
+
+	project, err := url.PathUnescape(project)
+	if err != nil {
+		return "", "", invalid
+	}
+	segments := strings.Split(memoryPath, "/")
+	for i, segment := range segments {
+		if segments[i], err = url.PathUnescape(segment); err != nil {
+			return "", "", invalid
+		}
+	}
+	return project, strings.Join(segments, "/"), nil
+}
+
+// memoryPaths returns the paths of all markdown files in a directory structure, sorted
+func memoryPaths(structure contracts.DirStructure, prefix string) []string {
+	paths := []string{}
+	for name, children := range structure {
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if children != nil {
+			paths = append(paths, memoryPaths(children, prefix+name+"/")...)
+		} else if strings.HasSuffix(name, ".md") {
+			paths = append(paths, prefix+name)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
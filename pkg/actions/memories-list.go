@@ -11,9 +11,14 @@ import (
 // NewMemoriesListHandler creates a handler for listing knowledge with dependency injection
 func NewMemoriesListHandler(repo contracts.KnowledgeRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		dirStructure, err := repo.List()
+		project, err := request.RequireString("project")
 		if err != nil {
-			return mcp.NewToolResultError("Failed to list memories: " + err.Error()), nil
+			return mcp.NewToolResultError("Missing 'project' parameter: " + err.Error()), nil
+		}
+
+		dirStructure, err := repo.List(project)
+		if err != nil {
+			return knowledgeErrorResult("Failed to list memories", err), nil
 		}
 
 		data, err := json.Marshal(dirStructure)
@@ -0,0 +1,44 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewMemoriesRelatedHandler creates a handler for finding related knowledge with dependency injection
+func NewMemoriesRelatedHandler(repo contracts.KnowledgeRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		project, err := request.RequireString("project")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'project' parameter: " + err.Error()), nil
+		}
+
+		query := contracts.RelatedQuery{
+			Text:  request.GetString("query", ""),
+			Path:  request.GetString("path", ""),
+			Limit: request.GetInt("limit", 0),
+		}
+		if (query.Text == "") == (query.Path == "") {
+			return mcp.NewToolResultError("Exactly one of 'query' or 'path' is required"), nil
+		}
+
+		results, err := repo.Related(project, query)
+		if err != nil {
+			return knowledgeErrorResult("Failed to find related memories", err), nil
+		}
+
+		result := map[string]interface{}{
+			"results": results,
+			"count":   len(results),
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
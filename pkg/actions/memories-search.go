@@ -0,0 +1,43 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewMemoriesSearchHandler creates a handler for searching knowledge with dependency injection
+func NewMemoriesSearchHandler(repo contracts.KnowledgeRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		project, err := request.RequireString("project")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'project' parameter: " + err.Error()), nil
+		}
+		query, err := request.RequireString("query")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'query' parameter: " + err.Error()), nil
+		}
+
+		results, err := repo.Search(project, contracts.SearchQuery{
+			Query:      query,
+			PathPrefix: request.GetString("path_prefix", ""),
+			Limit:      request.GetInt("limit", 0),
+		})
+		if err != nil {
+			return knowledgeErrorResult("Failed to search memories", err), nil
+		}
+
+		result := map[string]interface{}{
+			"results": results,
+			"count":   len(results),
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
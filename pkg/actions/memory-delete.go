@@ -10,12 +10,16 @@ import (
 // NewMemoryDeleteHandler creates a handler for deleting knowledge with dependency injection
 func NewMemoryDeleteHandler(repo contracts.KnowledgeRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		project, err := request.RequireString("project")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'project' parameter: " + err.Error()), nil
+		}
 		path, err := request.RequireString("path")
 		if err != nil {
 			return mcp.NewToolResultError("Missing 'path' parameter: " + err.Error()), nil
 		}
-		if err := repo.Delete(path); err != nil {
-			return mcp.NewToolResultError("Failed to delete file: " + err.Error()), nil
+		if err := repo.Delete(project, path); err != nil {
+			return knowledgeErrorResult("Failed to delete file", err), nil
 		}
 		return mcp.NewToolResultText("Memory deleted successfully."), nil
 	}
@@ -10,13 +10,17 @@ import (
 // NewMemoryGetHandler creates a handler for reading knowledge with dependency injection
 func NewMemoryGetHandler(repo contracts.KnowledgeRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		project, err := request.RequireString("project")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'project' parameter: " + err.Error()), nil
+		}
 		path, err := request.RequireString("path")
 		if err != nil {
 			return mcp.NewToolResultError("Missing 'path' parameter: " + err.Error()), nil
 		}
-		content, err := repo.Read(path)
+		content, err := repo.Read(project, path)
 		if err != nil {
-			return mcp.NewToolResultError("Failed to read file: " + err.Error()), nil
+			return knowledgeErrorResult("Failed to read file", err), nil
 		}
 		return mcp.NewToolResultText(content), nil
 	}
@@ -10,6 +10,10 @@ import (
 // NewMemoryStoreHandler creates a handler for storing knowledge with dependency injection
 func NewMemoryStoreHandler(repo contracts.KnowledgeRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		project, err := request.RequireString("project")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'project' parameter: " + err.Error()), nil
+		}
 		path, err := request.RequireString("path")
 		if err != nil {
 			return mcp.NewToolResultError("Missing 'path' parameter: " + err.Error()), nil
@@ -18,8 +22,8 @@ func NewMemoryStoreHandler(repo contracts.KnowledgeRepository) func(context.Cont
 		if err != nil {
 			return mcp.NewToolResultError("Missing 'content' parameter: " + err.Error()), nil
 		}
-		if err := repo.Write(path, content); err != nil {
-			return mcp.NewToolResultError("Failed to write file: " + err.Error()), nil
+		if err := repo.Write(project, path, content); err != nil {
+			return knowledgeErrorResult("Failed to write file", err), nil
 		}
 		return mcp.NewToolResultText("Memory stored successfully."), nil
 	}
@@ -3,6 +3,7 @@ package actions
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -24,6 +25,7 @@ func TestMemoryStoreHandler(t *testing.T) {
 			Params: mcp.CallToolParams{
 				Name: "memory-store",
 				Arguments: map[string]interface{}{
+					"project": "test-project",
 					"path":    "test.md",
 					"content": "# Test Content\nThis is a test.",
 				},
@@ -57,7 +59,7 @@ func TestMemoryStoreHandler(t *testing.T) {
 		}
 
 		// Verify the content was actually stored
-		stored, err := repo.Read("test.md")
+		stored, err := repo.Read("test-project", "test.md")
 		if err != nil {
 			t.Fatalf("Failed to read stored content: %v", err)
 		}
@@ -71,6 +73,7 @@ func TestMemoryStoreHandler(t *testing.T) {
 			Params: mcp.CallToolParams{
 				Name: "memory-store",
 				Arguments: map[string]interface{}{
+					"project": "test-project",
 					"content": "test content",
 				},
 			},
@@ -86,12 +89,64 @@ func TestMemoryStoreHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("path traversal is rejected", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "memory-store",
+				Arguments: map[string]interface{}{
+					"project": "test-project",
+					"path":    "../../.ssh/authorized_keys",
+					"content": "ssh-rsa AAAA",
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if !result.IsError {
+			t.Fatal("Expected error result for path traversal")
+		}
+
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		if !strings.HasPrefix(textContent.Text, "Invalid path") {
+			t.Errorf("Expected invalid path message, got: %s", textContent.Text)
+		}
+	})
+
+	t.Run("missing project parameter", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "memory-store",
+				Arguments: map[string]interface{}{
+					"path":    "test.md",
+					"content": "test content",
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if !result.IsError {
+			t.Error("Expected error result for missing project")
+		}
+	})
+
 	t.Run("missing content parameter", func(t *testing.T) {
 		request := mcp.CallToolRequest{
 			Params: mcp.CallToolParams{
 				Name: "memory-store",
 				Arguments: map[string]interface{}{
-					"path": "test.md",
+					"project": "test-project",
+					"path":    "test.md",
 				},
 			},
 		}
@@ -120,7 +175,7 @@ func TestMemoryGetHandler(t *testing.T) {
 	// Setup test data
 	testPath := "test.md"
 	testContent := "# Test Memory\nThis is test content."
-	if err := repo.Write(testPath, testContent); err != nil {
+	if err := repo.Write("test-project", testPath, testContent); err != nil {
 		t.Fatalf("Failed to setup test data: %v", err)
 	}
 
@@ -129,7 +184,8 @@ func TestMemoryGetHandler(t *testing.T) {
 			Params: mcp.CallToolParams{
 				Name: "memory-get",
 				Arguments: map[string]interface{}{
-					"path": testPath,
+					"project": "test-project",
+					"path":    testPath,
 				},
 			},
 		}
@@ -164,8 +220,10 @@ func TestMemoryGetHandler(t *testing.T) {
 	t.Run("missing path parameter", func(t *testing.T) {
 		request := mcp.CallToolRequest{
 			Params: mcp.CallToolParams{
-				Name:      "memory-get",
-				Arguments: map[string]interface{}{},
+				Name: "memory-get",
+				Arguments: map[string]interface{}{
+					"project": "test-project",
+				},
 			},
 		}
 
@@ -184,7 +242,8 @@ func TestMemoryGetHandler(t *testing.T) {
 			Params: mcp.CallToolParams{
 				Name: "memory-get",
 				Arguments: map[string]interface{}{
-					"path": "non-existent.md",
+					"project": "test-project",
+					"path":    "non-existent.md",
 				},
 			},
 		}
@@ -211,18 +270,20 @@ func TestMemoriesListHandler(t *testing.T) {
 	handler := NewMemoriesListHandler(repo)
 
 	// Setup test data
-	if err := repo.Write("file1.md", "content1"); err != nil {
+	if err := repo.Write("test-project", "file1.md", "content1"); err != nil {
 		t.Fatalf("Failed to setup test data: %v", err)
 	}
-	if err := repo.Write("dir/file2.md", "content2"); err != nil {
+	if err := repo.Write("test-project", "dir/file2.md", "content2"); err != nil {
 		t.Fatalf("Failed to setup test data: %v", err)
 	}
 
 	t.Run("successful list", func(t *testing.T) {
 		request := mcp.CallToolRequest{
 			Params: mcp.CallToolParams{
-				Name:      "memories-list",
-				Arguments: map[string]interface{}{},
+				Name: "memories-list",
+				Arguments: map[string]interface{}{
+					"project": "test-project",
+				},
 			},
 		}
 
@@ -268,7 +329,7 @@ func TestMemoryDeleteHandler(t *testing.T) {
 
 	// Setup test data
 	testPath := "test.md"
-	if err := repo.Write(testPath, "test content"); err != nil {
+	if err := repo.Write("test-project", testPath, "test content"); err != nil {
 		t.Fatalf("Failed to setup test data: %v", err)
 	}
 
@@ -277,7 +338,8 @@ func TestMemoryDeleteHandler(t *testing.T) {
 			Params: mcp.CallToolParams{
 				Name: "memory-delete",
 				Arguments: map[string]interface{}{
-					"path": testPath,
+					"project": "test-project",
+					"path":    testPath,
 				},
 			},
 		}
@@ -309,7 +371,7 @@ func TestMemoryDeleteHandler(t *testing.T) {
 		}
 
 		// Verify the file was actually deleted
-		_, err = repo.Read(testPath)
+		_, err = repo.Read("test-project", testPath)
 		if err == nil {
 			t.Error("Expected file to be deleted")
 		}
@@ -318,8 +380,10 @@ func TestMemoryDeleteHandler(t *testing.T) {
 	t.Run("missing path parameter", func(t *testing.T) {
 		request := mcp.CallToolRequest{
 			Params: mcp.CallToolParams{
-				Name:      "memory-delete",
-				Arguments: map[string]interface{}{},
+				Name: "memory-delete",
+				Arguments: map[string]interface{}{
+					"project": "test-project",
+				},
 			},
 		}
 
@@ -333,3 +397,213 @@ func TestMemoryDeleteHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestProjectsListHandler(t *testing.T) {
+	baseDir := t.TempDir()
+	repo, err := knowledge.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	handler := NewProjectsListHandler(repo)
+
+	// Setup test data
+	if err := repo.Write("project-a", "file1.md", "content1"); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+	if err := repo.Write("project-b", "file2.md", "content2"); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	t.Run("successful list", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "projects-list",
+				Arguments: map[string]interface{}{},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if result.IsError {
+			t.Fatal("Handler returned error result")
+		}
+
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+
+		var listResult map[string]interface{}
+		if err := json.Unmarshal([]byte(textContent.Text), &listResult); err != nil {
+			t.Fatalf("Result is not valid JSON: %v", err)
+		}
+
+		if count, ok := listResult["count"].(float64); !ok || int(count) != 2 {
+			t.Errorf("Expected count 2, got: %v", listResult["count"])
+		}
+	})
+}
+
+func TestMemoriesSearchHandler(t *testing.T) {
+	baseDir := t.TempDir()
+	repo, err := knowledge.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	handler := NewMemoriesSearchHandler(repo)
+
+	// Setup test data
+	if err := repo.Write("test-project", "auth.md", "# Auth\nThe login flow uses tokens."); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+	if err := repo.Write("test-project", "db.md", "# Database\nPostgreSQL"); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	t.Run("successful search", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "memories-search",
+				Arguments: map[string]interface{}{
+					"project": "test-project",
+					"query":   "login",
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if result.IsError {
+			t.Fatal("Handler returned error result")
+		}
+
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+
+		var searchResult struct {
+			Count   int `json:"count"`
+			Results []struct {
+				Path     string `json:"path"`
+				Snippets []struct {
+					Line int `json:"line"`
+				} `json:"snippets"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal([]byte(textContent.Text), &searchResult); err != nil {
+			t.Fatalf("Result is not valid JSON: %v", err)
+		}
+
+		if searchResult.Count != 1 || searchResult.Results[0].Path != "auth.md" {
+			t.Errorf("Expected auth.md as only result, got: %s", textContent.Text)
+		}
+		if len(searchResult.Results[0].Snippets) != 1 || searchResult.Results[0].Snippets[0].Line != 2 {
+			t.Errorf("Expected snippet on line 2, got: %s", textContent.Text)
+		}
+	})
+
+	t.Run("missing query parameter", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "memories-search",
+				Arguments: map[string]interface{}{
+					"project": "test-project",
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if !result.IsError {
+			t.Error("Expected error result for missing query")
+		}
+	})
+}
+
+func TestMemoriesRelatedHandler(t *testing.T) {
+	baseDir := t.TempDir()
+	repo, err := knowledge.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	handler := NewMemoriesRelatedHandler(repo)
+
+	// Setup test data
+	if err := repo.Write("test-project", "auth.md", "The authentication flow issues tokens."); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+	if err := repo.Write("test-project", "login.md", "Authenticated users get tokens."); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	t.Run("successful related by path", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "memories-related",
+				Arguments: map[string]interface{}{
+					"project": "test-project",
+					"path":    "auth.md",
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if result.IsError {
+			t.Fatal("Handler returned error result")
+		}
+
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+
+		var relatedResult map[string]interface{}
+		if err := json.Unmarshal([]byte(textContent.Text), &relatedResult); err != nil {
+			t.Fatalf("Result is not valid JSON: %v", err)
+		}
+
+		if count, ok := relatedResult["count"].(float64); !ok || int(count) != 1 {
+			t.Errorf("Expected count 1, got: %v", relatedResult["count"])
+		}
+	})
+
+	t.Run("missing query and path", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "memories-related",
+				Arguments: map[string]interface{}{
+					"project": "test-project",
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if !result.IsError {
+			t.Error("Expected error result without query and path")
+		}
+	})
+}
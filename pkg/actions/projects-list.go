@@ -0,0 +1,30 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewProjectsListHandler creates a handler for listing knowledge projects with dependency injection
+func NewProjectsListHandler(repo contracts.KnowledgeRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		projects, err := repo.ListProjects()
+		if err != nil {
+			return mcp.NewToolResultError("Failed to list projects: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"projects": projects,
+			"count":    len(projects),
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -0,0 +1,148 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/template"
+)
+
+// notifyingSession is a client session that collects the notifications sent to it
+type notifyingSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *notifyingSession) Initialize()       {}
+func (s *notifyingSession) Initialized() bool { return true }
+func (s *notifyingSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+func (s *notifyingSession) SessionID() string { return "notifying" }
+
+// countNotifications drains the notifications of a session and counts those with the given method
+func (s *notifyingSession) countNotifications(method string) int {
+	count := 0
+	for {
+		select {
+		case notification := <-s.notifications:
+			if notification.Method == method {
+				count++
+			}
+		default:
+			return count
+		}
+	}
+}
+
+func TestTemplatePrompts(t *testing.T) {
+	repo, err := template.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithPromptCapabilities(true))
+	session := &notifyingSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to register session: %v", err)
+	}
+	ctx := mcpServer.WithContext(context.Background(), session)
+
+	templates := NewTemplatePromptRepository(repo, mcpServer)
+	if err := templates.RefreshPrompts(); err != nil {
+		t.Fatalf("Failed to refresh prompts: %v", err)
+	}
+	session.countNotifications(mcp.MethodNotificationPromptsListChanged)
+
+	createHandler := NewTaskTemplateCreateHandler(templates)
+	updateHandler := NewTaskTemplateUpdateHandler(templates)
+	deleteHandler := NewTaskTemplateDeleteHandler(templates)
+
+	call := func(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) {
+		result, err := handler(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if result.IsError {
+			textContent, _ := mcp.AsTextContent(result.Content[0])
+			t.Fatalf("Expected success, got: %s", textContent.Text)
+		}
+	}
+
+	send := func(t *testing.T, method string, params interface{}) json.RawMessage {
+		message, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+		response, err := json.Marshal(mcpServer.HandleMessage(ctx, message))
+		if err != nil {
+			t.Fatalf("Failed to marshal response: %v", err)
+		}
+		return response
+	}
+
+	review := `{"id": "review", "name": "Review", "description": "Reviews a change",
+		"parameters": {"change": {"type": "string", "description": "The change to review", "required": true}, "depth": {"type": "enum", "values": ["quick", "deep"], "default": "quick"}},
+		"tasks": ["Read ${change}", "Do a ${depth} review"]}`
+
+	t.Run("created templates become prompts", func(t *testing.T) {
+		call(t, createHandler, map[string]interface{}{"template": review})
+		if count := session.countNotifications(mcp.MethodNotificationPromptsListChanged); count != 1 {
+			t.Errorf("Expected 1 list_changed notification, got %d", count)
+		}
+
+		var response struct {
+			Result mcp.ListPromptsResult `json:"result"`
+		}
+		if err := json.Unmarshal(send(t, "prompts/list", map[string]interface{}{}), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(response.Result.Prompts) != 1 {
+			t.Fatalf("Expected 1 prompt, got %+v", response.Result.Prompts)
+		}
+		prompt := response.Result.Prompts[0]
+		if prompt.Name != "review" || len(prompt.Arguments) != 2 {
+			t.Fatalf("Expected review prompt with 2 arguments, got %+v", prompt)
+		}
+		if prompt.Arguments[0].Name != "change" || !prompt.Arguments[0].Required || prompt.Arguments[1].Required {
+			t.Errorf("Expected only 'change' to be required, got %+v", prompt.Arguments)
+		}
+		if !strings.Contains(prompt.Arguments[1].Description, "quick, deep") {
+			t.Errorf("Expected enum values in the description, got %q", prompt.Arguments[1].Description)
+		}
+	})
+
+	t.Run("getting a prompt resolves the tasks", func(t *testing.T) {
+		response := string(send(t, "prompts/get", map[string]interface{}{"name": "review", "arguments": map[string]string{"change": "PR 42"}}))
+		for _, expected := range []string{"Read PR 42", "Do a quick review", "task-template-instantiate"} {
+			if !strings.Contains(response, expected) {
+				t.Errorf("Expected prompt to contain %q, got: %s", expected, response)
+			}
+		}
+
+		response = string(send(t, "prompts/get", map[string]interface{}{"name": "review", "arguments": map[string]string{"change": "PR 42", "depth": "sloppy"}}))
+		if !strings.Contains(response, "error") {
+			t.Errorf("Expected invalid arguments to be rejected, got: %s", response)
+		}
+	})
+
+	t.Run("updates and deletes refresh the prompts", func(t *testing.T) {
+		updated := strings.Replace(review, "Reviews a change", "Reviews a change thoroughly", 1)
+		call(t, updateHandler, map[string]interface{}{"template": updated})
+		if !strings.Contains(string(send(t, "prompts/list", map[string]interface{}{})), "Reviews a change thoroughly") {
+			t.Error("Expected the prompt description to be updated")
+		}
+
+		call(t, deleteHandler, map[string]interface{}{"template_id": "review"})
+		if strings.Contains(string(send(t, "prompts/list", map[string]interface{}{})), "review") {
+			t.Error("Expected the prompt to be removed")
+		}
+		if count := session.countNotifications(mcp.MethodNotificationPromptsListChanged); count != 2 {
+			t.Errorf("Expected 2 list_changed notifications, got %d", count)
+		}
+	})
+}
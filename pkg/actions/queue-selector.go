@@ -0,0 +1,85 @@
+package actions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// stdioSessionID is the session ID mcp-go uses for every stdio client
+const stdioSessionID = "stdio"
+
+// processSessionID identifies the stdio client of this process. mcp-go gives every
+// stdio process the same session ID, so parallel editor windows need one of their own.
+var processSessionID = newProcessSessionID()
+
+// newProcessSessionID returns a session ID that is unique across processes and a valid queue name
+func newProcessSessionID() string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("stdio-%s-%s", time.Now().Format("20060102-150405"), hex.EncodeToString(suffix))
+}
+
+// QueueSelector remembers which task queue each client session works on.
+// Sessions use a queue named after their session ID until they switch to another one,
+// stdio clients one named after their server process.
+type QueueSelector struct {
+	selected map[string]string
+	mutex    sync.RWMutex
+}
+
+// NewQueueSelector creates a queue selector without any switched sessions
+func NewQueueSelector() *QueueSelector {
+	return &QueueSelector{
+		selected: make(map[string]string),
+	}
+}
+
+// sessionID returns the ID of the client session of a request, or "" without one
+func sessionID(ctx context.Context) string {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return ""
+	}
+	if id := session.SessionID(); id != stdioSessionID {
+		return id
+	}
+	return processSessionID
+}
+
+// Queue returns the task queue of the client session of a request
+func (s *QueueSelector) Queue(ctx context.Context) string {
+	id := sessionID(ctx)
+
+	s.mutex.RLock()
+	queue, switched := s.selected[id]
+	s.mutex.RUnlock()
+	if switched {
+		return queue
+	}
+
+	// Session IDs are only usable if they are valid queue names
+	if id == "" || contracts.ValidateQueueName(id) != nil {
+		return contracts.DefaultQueue
+	}
+	return id
+}
+
+// Switch makes the client session of a request work on another queue
+func (s *QueueSelector) Switch(ctx context.Context, queue string) error {
+	if err := contracts.ValidateQueueName(queue); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.selected[sessionID(ctx)] = queue
+	return nil
+}
@@ -0,0 +1,124 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/task"
+)
+
+// testSession is a client session with a fixed ID
+type testSession struct {
+	id string
+}
+
+func (s *testSession) Initialize()                                         {}
+func (s *testSession) Initialized() bool                                   { return true }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s *testSession) SessionID() string                                   { return s.id }
+
+// sessionContext returns a context of a request made in the given client session
+func sessionContext(id string) context.Context {
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	return mcpServer.WithContext(context.Background(), &testSession{id: id})
+}
+
+func TestTaskQueuesPerSession(t *testing.T) {
+	repo, err := task.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	queues := NewQueueSelector()
+	addHandler := NewTasksAddHandler(repo, queues)
+	getHandler := NewTaskGetHandler(repo, queues)
+	listHandler := NewTaskQueuesListHandler(repo, queues)
+	switchHandler := NewTaskQueueSwitchHandler(queues)
+	archiveHandler := NewTaskQueueArchiveHandler(repo, queues)
+
+	call := func(t *testing.T, ctx context.Context, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) (*mcp.CallToolResult, map[string]interface{}) {
+		result, err := handler(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		var decoded map[string]interface{}
+		_ = json.Unmarshal([]byte(textContent.Text), &decoded)
+		return result, decoded
+	}
+
+	windowA := sessionContext("window-a")
+	windowB := sessionContext("window-b")
+
+	call(t, windowA, addHandler, map[string]interface{}{"contents": []interface{}{"Task of A"}})
+	call(t, windowB, addHandler, map[string]interface{}{"contents": []interface{}{"Task of B"}})
+
+	t.Run("sessions get their own tasks", func(t *testing.T) {
+		_, got := call(t, windowB, getHandler, map[string]interface{}{})
+		if got["content"] != "Task of B" {
+			t.Errorf("Expected 'Task of B', got %v", got["content"])
+		}
+	})
+
+	t.Run("sessions without ID use the default queue", func(t *testing.T) {
+		if queue := queues.Queue(context.Background()); queue != contracts.DefaultQueue {
+			t.Errorf("Expected default queue, got %s", queue)
+		}
+	})
+
+	t.Run("stdio processes get their own queue", func(t *testing.T) {
+		queue := queues.Queue(sessionContext("stdio"))
+		if queue != processSessionID || queue == contracts.DefaultQueue || contracts.ValidateQueueName(queue) != nil {
+			t.Errorf("Expected the queue of this process, got %s", queue)
+		}
+		if other := newProcessSessionID(); other == processSessionID {
+			t.Errorf("Expected another process to get another queue, got %s twice", other)
+		}
+	})
+
+	t.Run("list queues", func(t *testing.T) {
+		_, got := call(t, windowA, listHandler, map[string]interface{}{})
+		if got["current"] != "window-a" {
+			t.Errorf("Expected current queue 'window-a', got %v", got["current"])
+		}
+		if count, ok := got["count"].(float64); !ok || int(count) != 2 {
+			t.Errorf("Expected 2 queues, got %v", got["count"])
+		}
+	})
+
+	t.Run("switch queue", func(t *testing.T) {
+		call(t, windowB, switchHandler, map[string]interface{}{"queue": "window-a"})
+		_, got := call(t, windowB, getHandler, map[string]interface{}{})
+		if got["content"] != "Task of A" {
+			t.Errorf("Expected 'Task of A' after switching, got %v", got["content"])
+		}
+
+		result, _ := call(t, windowB, switchHandler, map[string]interface{}{"queue": "../tasks"})
+		if !result.IsError {
+			t.Error("Expected error result for invalid queue name")
+		}
+	})
+
+	t.Run("archive current queue", func(t *testing.T) {
+		result, got := call(t, windowB, archiveHandler, map[string]interface{}{})
+		if result.IsError || got["queue"] != "window-a" {
+			t.Fatalf("Expected window-a to be archived, got %v", got)
+		}
+
+		taskQueues, err := repo.ListQueues()
+		if err != nil {
+			t.Fatalf("Failed to list queues: %v", err)
+		}
+		if len(taskQueues) != 1 || taskQueues[0].Name != "window-b" {
+			t.Errorf("Expected only window-b to remain, got %+v", taskQueues)
+		}
+	})
+}
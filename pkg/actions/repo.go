@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/mstrehse/mcp-brain/pkg/contracts"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/decision"
 	"github.com/mstrehse/mcp-brain/pkg/repositories/knowledge"
 	"github.com/mstrehse/mcp-brain/pkg/repositories/task"
 	"github.com/mstrehse/mcp-brain/pkg/repositories/template"
@@ -14,6 +15,7 @@ type Repositories struct {
 	Knowledge contracts.KnowledgeRepository
 	Task      contracts.TaskRepository
 	Template  contracts.TaskTemplateRepository
+	Decision  contracts.DecisionRepository
 }
 
 // NewRepositories creates a new instance of Repositories with all dependencies initialized
@@ -34,10 +36,16 @@ func NewRepositories(baseDir string) (*Repositories, error) {
 		return nil, fmt.Errorf("failed to initialize template repository: %w", err)
 	}
 
+	decisionRepo, err := decision.NewFileRepository(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize decision repository: %w", err)
+	}
+
 	return &Repositories{
 		Knowledge: knowledgeRepo,
 		Task:      taskRepo,
 		Template:  templateRepo,
+		Decision:  decisionRepo,
 	}, nil
 }
 
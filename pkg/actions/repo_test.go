@@ -54,11 +54,11 @@ func TestNewRepositories(t *testing.T) {
 	path := "init.md"
 	content := "Initialization test"
 
-	if err := repositories.Knowledge.Write(path, content); err != nil {
+	if err := repositories.Knowledge.Write("test-project", path, content); err != nil {
 		t.Fatalf("Write failed with initialized repo: %v", err)
 	}
 
-	readContent, err := repositories.Knowledge.Read(path)
+	readContent, err := repositories.Knowledge.Read("test-project", path)
 	if err != nil {
 		t.Fatalf("Read failed with initialized repo: %v", err)
 	}
@@ -67,7 +67,7 @@ func TestNewRepositories(t *testing.T) {
 	}
 
 	// Test that the task repository is usable
-	tasks, err := repositories.Task.AddTasks([]string{"test task 1", "test task 2"})
+	tasks, err := repositories.Task.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"test task 1", "test task 2"}))
 	if err != nil {
 		t.Fatalf("AddTasks failed: %v", err)
 	}
@@ -76,7 +76,7 @@ func TestNewRepositories(t *testing.T) {
 	}
 
 	// Test retrieving a task
-	task, err := repositories.Task.GetTask()
+	task, err := repositories.Task.GetTask(contracts.DefaultQueue)
 	if err != nil {
 		t.Fatalf("GetTask failed: %v", err)
 	}
@@ -0,0 +1,180 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/knowledge"
+)
+
+func TestMemoryResources(t *testing.T) {
+	repo, err := knowledge.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	if err := repo.Write("alpha", "notes/architecture", "# Architecture"); err != nil {
+		t.Fatalf("Failed to write memory: %v", err)
+	}
+	if err := repo.Write("alpha", "overview", "# Overview"); err != nil {
+		t.Fatalf("Failed to write memory: %v", err)
+	}
+	if err := repo.Write("beta", "todo", "# Todo"); err != nil {
+		t.Fatalf("Failed to write memory: %v", err)
+	}
+
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithResourceCapabilities(false, true), server.WithPaginationLimit(2))
+	session := &notifyingSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to register session: %v", err)
+	}
+	ctx := mcpServer.WithContext(context.Background(), session)
+
+	resources := NewKnowledgeResourceRepository(repo, mcpServer)
+	if err := resources.RefreshResources(); err != nil {
+		t.Fatalf("Failed to refresh resources: %v", err)
+	}
+	session.countNotifications(mcp.MethodNotificationResourcesListChanged)
+
+	storeHandler := NewMemoryStoreHandler(resources)
+	deleteHandler := NewMemoryDeleteHandler(resources)
+
+	call := func(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) {
+		result, err := handler(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if result.IsError {
+			textContent, _ := mcp.AsTextContent(result.Content[0])
+			t.Fatalf("Expected success, got: %s", textContent.Text)
+		}
+	}
+
+	send := func(t *testing.T, method string, params interface{}) []byte {
+		message, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+		response, err := json.Marshal(mcpServer.HandleMessage(ctx, message))
+		if err != nil {
+			t.Fatalf("Failed to marshal response: %v", err)
+		}
+		return response
+	}
+
+	listAll := func(t *testing.T) []string {
+		uris := []string{}
+		cursor := ""
+		for pages := 0; pages < 10; pages++ {
+			params := map[string]interface{}{}
+			if cursor != "" {
+				params["cursor"] = cursor
+			}
+			var response struct {
+				Result mcp.ListResourcesResult `json:"result"`
+			}
+			if err := json.Unmarshal(send(t, "resources/list", params), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			for _, resource := range response.Result.Resources {
+				uris = append(uris, resource.URI)
+			}
+			if cursor = string(response.Result.NextCursor); cursor == "" {
+				break
+			}
+		}
+		return uris
+	}
+
+	t.Run("memories are listed page by page", func(t *testing.T) {
+		uris := listAll(t)
+		expected := []string{"brain://alpha/notes/architecture.md", "brain://alpha/overview.md", "brain://beta/todo.md"}
+		if strings.Join(uris, " ") != strings.Join(expected, " ") {
+			t.Errorf("Expected %v, got %v", expected, uris)
+		}
+	})
+
+	t.Run("memories can be read by URI", func(t *testing.T) {
+		response := string(send(t, "resources/read", map[string]interface{}{"uri": "brain://alpha/notes/architecture.md"}))
+		if !strings.Contains(response, "# Architecture") || !strings.Contains(response, "text/markdown") {
+			t.Errorf("Expected memory content, got: %s", response)
+		}
+	})
+
+	t.Run("the resource template looks up unlisted memories", func(t *testing.T) {
+		if err := repo.Write("gamma", "deep/nested/memory", "# Nested"); err != nil {
+			t.Fatalf("Failed to write memory: %v", err)
+		}
+		response := string(send(t, "resources/read", map[string]interface{}{"uri": "brain://gamma/deep/nested/memory.md"}))
+		if !strings.Contains(response, "# Nested") {
+			t.Errorf("Expected memory content, got: %s", response)
+		}
+
+		response = string(send(t, "resources/templates/list", map[string]interface{}{}))
+		if !strings.Contains(response, MemoryURITemplate) {
+			t.Errorf("Expected the memory resource template, got: %s", response)
+		}
+	})
+
+	t.Run("storing and deleting memories notifies clients", func(t *testing.T) {
+		call(t, storeHandler, map[string]interface{}{"project": "beta", "path": "ideas", "content": "# Ideas"})
+		if count := session.countNotifications(mcp.MethodNotificationResourcesListChanged); count != 1 {
+			t.Errorf("Expected 1 list_changed notification for a new memory, got %d", count)
+		}
+		if uris := listAll(t); len(uris) != 4 {
+			t.Errorf("Expected the new memory to be listed, got %v", uris)
+		}
+
+		call(t, storeHandler, map[string]interface{}{"project": "beta", "path": "ideas", "content": "# More ideas"})
+		var updated []string
+		for len(session.notifications) > 0 {
+			notification := <-session.notifications
+			if notification.Method == mcp.MethodNotificationResourcesListChanged {
+				t.Error("Expected no list_changed notification for a changed memory")
+			}
+			if notification.Method == mcp.MethodNotificationResourceUpdated {
+				updated = append(updated, notification.Params.AdditionalFields["uri"].(string))
+			}
+		}
+		if len(updated) != 1 || updated[0] != "brain://beta/ideas.md" {
+			t.Errorf("Expected an updated notification for the memory, got %v", updated)
+		}
+
+		call(t, deleteHandler, map[string]interface{}{"project": "beta", "path": "ideas.md"})
+		if count := session.countNotifications(mcp.MethodNotificationResourcesListChanged); count != 1 {
+			t.Errorf("Expected 1 list_changed notification for a deleted memory, got %d", count)
+		}
+		if uris := listAll(t); len(uris) != 3 {
+			t.Errorf("Expected the deleted memory to be gone, got %v", uris)
+		}
+	})
+
+	t.Run("names with reserved characters are escaped", func(t *testing.T) {
+		call(t, storeHandler, map[string]interface{}{"project": "my project", "path": "plans/v1 #draft?", "content": "# Draft"})
+		uri := MemoryURI("my project", "plans/v1 #draft?")
+		if uri != "brain://my%20project/plans/v1%20%23draft%3F.md" {
+			t.Errorf("Expected escaped URI, got %s", uri)
+		}
+
+		project, memoryPath, err := parseMemoryURI(uri)
+		if err != nil || project != "my project" || memoryPath != "plans/v1 #draft?.md" {
+			t.Errorf("Expected the URI to parse back to the memory, got %q, %q, %v", project, memoryPath, err)
+		}
+
+		response := string(send(t, "resources/read", map[string]interface{}{"uri": uri}))
+		if !strings.Contains(response, "# Draft") {
+			t.Errorf("Expected memory content, got: %s", response)
+		}
+
+		if err := repo.Write("my project", "unlisted #1", "# Unlisted"); err != nil {
+			t.Fatalf("Failed to write memory: %v", err)
+		}
+		response = string(send(t, "resources/read", map[string]interface{}{"uri": MemoryURI("my project", "unlisted #1")}))
+		if !strings.Contains(response, "# Unlisted") {
+			t.Errorf("Expected memory content through the resource template, got: %s", response)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewTaskCompleteHandler creates a handler for marking tasks as done with dependency injection
+func NewTaskCompleteHandler(repo contracts.TaskRepository, queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		taskID, err := request.RequireString("task_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'task_id' parameter: " + err.Error()), nil
+		}
+
+		task, err := repo.FinishTask(queues.Queue(ctx), taskID, contracts.TaskStatusDone, request.GetString("note", ""))
+		if err != nil {
+			return mcp.NewToolResultError("Failed to complete task: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"message": "Task completed successfully",
+			"task":    task,
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal task result: " + err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -0,0 +1,40 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewTaskFailHandler creates a handler for marking tasks as failed with dependency injection
+func NewTaskFailHandler(repo contracts.TaskRepository, queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		taskID, err := request.RequireString("task_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'task_id' parameter: " + err.Error()), nil
+		}
+		note, err := request.RequireString("note")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'note' parameter: " + err.Error()), nil
+		}
+
+		task, err := repo.FinishTask(queues.Queue(ctx), taskID, contracts.TaskStatusFailed, note)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to fail task: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"message": "Task marked as failed",
+			"task":    task,
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal task result: " + err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -9,9 +9,9 @@ import (
 )
 
 // NewTaskGetHandler creates a handler for getting tasks with dependency injection
-func NewTaskGetHandler(repo contracts.TaskRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func NewTaskGetHandler(repo contracts.TaskRepository, queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		task, err := repo.GetTask()
+		task, err := repo.GetTask(queues.Queue(ctx))
 		if err != nil {
 			return mcp.NewToolResultError("Failed to get task: " + err.Error()), nil
 		}
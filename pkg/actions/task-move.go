@@ -0,0 +1,50 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// taskPlacementFromRequest reads the front, after and position parameters of a request
+func taskPlacementFromRequest(request mcp.CallToolRequest) contracts.TaskPlacement {
+	return contracts.TaskPlacement{
+		Front:    request.GetBool("front", false),
+		After:    request.GetString("after", ""),
+		Position: request.GetInt("position", 0),
+	}
+}
+
+// NewTaskMoveHandler creates a handler for reordering tasks with dependency injection
+func NewTaskMoveHandler(repo contracts.TaskRepository, queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		taskID, err := request.RequireString("task_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'task_id' parameter: " + err.Error()), nil
+		}
+
+		placement := taskPlacementFromRequest(request)
+		if placement == (contracts.TaskPlacement{}) {
+			return mcp.NewToolResultError("Specify where to move the task with 'front', 'after' or 'position'"), nil
+		}
+
+		task, err := repo.MoveTask(queues.Queue(ctx), taskID, placement)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to move task: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"message": "Task moved successfully",
+			"task":    task,
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal task result: " + err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -0,0 +1,26 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewTaskPeekHandler creates a handler for looking at the next task without consuming it
+func NewTaskPeekHandler(repo contracts.TaskRepository, queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		task, err := repo.PeekTask(queues.Queue(ctx))
+		if err != nil {
+			return mcp.NewToolResultError("Failed to peek task: " + err.Error()), nil
+		}
+
+		data, err := json.Marshal(task)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal task result: " + err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -0,0 +1,34 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewTaskQueueArchiveHandler creates a handler for archiving task queues with dependency injection
+func NewTaskQueueArchiveHandler(repo contracts.TaskRepository, queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		queue := request.GetString("queue", "")
+		if queue == "" {
+			queue = queues.Queue(ctx)
+		}
+
+		if err := repo.ArchiveQueue(queue); err != nil {
+			return mcp.NewToolResultError("Failed to archive queue: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"message": "Queue archived successfully",
+			"queue":   queue,
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
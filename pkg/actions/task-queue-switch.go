@@ -0,0 +1,33 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// NewTaskQueueSwitchHandler creates a handler for switching the task queue of the current session
+func NewTaskQueueSwitchHandler(queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		queue, err := request.RequireString("queue")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'queue' parameter: " + err.Error()), nil
+		}
+
+		if err := queues.Switch(ctx, queue); err != nil {
+			return mcp.NewToolResultError("Failed to switch queue: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"message": "Switched to queue successfully",
+			"queue":   queue,
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
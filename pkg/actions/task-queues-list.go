@@ -0,0 +1,31 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewTaskQueuesListHandler creates a handler for listing task queues with dependency injection
+func NewTaskQueuesListHandler(repo contracts.TaskRepository, queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		taskQueues, err := repo.ListQueues()
+		if err != nil {
+			return mcp.NewToolResultError("Failed to list queues: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"queues":  taskQueues,
+			"count":   len(taskQueues),
+			"current": queues.Queue(ctx),
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -24,6 +24,9 @@ func NewTaskTemplateCreateHandler(repo contracts.TaskTemplateRepository) func(co
 		if err := validateTemplate(&template); err != nil {
 			return mcp.NewToolResultError("Template validation failed: " + err.Error()), nil
 		}
+		if err := validateIncludes(repo, &template); err != nil {
+			return mcp.NewToolResultError("Template validation failed: " + err.Error()), nil
+		}
 
 		if err := repo.CreateTemplate(&template); err != nil {
 			return mcp.NewToolResultError("Failed to create template: " + err.Error()), nil
@@ -33,6 +36,9 @@ func NewTaskTemplateCreateHandler(repo contracts.TaskTemplateRepository) func(co
 			"message":     "Template created successfully",
 			"template_id": template.ID,
 		}
+		if warnings := templateWarnings(&template); len(warnings) > 0 {
+			result["warnings"] = warnings
+		}
 
 		data, err := json.Marshal(result)
 		if err != nil {
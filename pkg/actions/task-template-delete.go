@@ -3,6 +3,8 @@ package actions
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mstrehse/mcp-brain/pkg/contracts"
@@ -22,6 +24,15 @@ func NewTaskTemplateDeleteHandler(repo contracts.TaskTemplateRepository) func(co
 			return mcp.NewToolResultError("Template not found: " + err.Error()), nil
 		}
 
+		// Deleting an included template breaks the templates that include it
+		including, err := includingTemplates(repo, templateID)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to check including templates: " + err.Error()), nil
+		}
+		if len(including) > 0 && !request.GetBool("force", false) {
+			return mcp.NewToolResultError(fmt.Sprintf("Template is still included by: %s. Update those templates first or set 'force' to delete it anyway", strings.Join(including, ", "))), nil
+		}
+
 		if err := repo.DeleteTemplate(templateID); err != nil {
 			return mcp.NewToolResultError("Failed to delete template: " + err.Error()), nil
 		}
@@ -30,6 +41,9 @@ func NewTaskTemplateDeleteHandler(repo contracts.TaskTemplateRepository) func(co
 			"message":     "Template deleted successfully",
 			"template_id": templateID,
 		}
+		if len(including) > 0 {
+			result["warnings"] = []string{fmt.Sprintf("Templates that include it can no longer be instantiated: %s", strings.Join(including, ", "))}
+		}
 
 		data, err := json.Marshal(result)
 		if err != nil {
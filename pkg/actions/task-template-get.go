@@ -16,7 +16,7 @@ func NewTaskTemplateGetHandler(repo contracts.TaskTemplateRepository) func(conte
 			return mcp.NewToolResultError("Missing 'template_id' parameter: " + err.Error()), nil
 		}
 
-		template, err := repo.GetTemplate(templateID)
+		template, err := repo.GetTemplateVersion(templateID, request.GetInt("version", 0))
 		if err != nil {
 			return mcp.NewToolResultError("Failed to get template: " + err.Error()), nil
 		}
@@ -0,0 +1,38 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewTaskTemplateHistoryHandler creates a handler for listing the versions of a template
+func NewTaskTemplateHistoryHandler(repo contracts.TaskTemplateRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		templateID, err := request.RequireString("template_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'template_id' parameter: " + err.Error()), nil
+		}
+
+		versions, err := repo.ListTemplateVersions(templateID)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to get template history: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"template_id":     templateID,
+			"current_version": versions[len(versions)-1].Version,
+			"versions":        versions,
+			"count":           len(versions),
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -3,13 +3,15 @@ package actions
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mstrehse/mcp-brain/pkg/contracts"
 )
 
-// NewTaskTemplateInstantiateHandler creates a handler for instantiating templates
-func NewTaskTemplateInstantiateHandler(repo contracts.TaskTemplateRepository, taskRepo contracts.TaskRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// NewTaskTemplateInstantiateHandler creates a handler for instantiating templates.
+// Templates with unmet prerequisites are only instantiated with 'force'.
+func NewTaskTemplateInstantiateHandler(repo contracts.TaskTemplateRepository, taskRepo contracts.TaskRepository, queues *QueueSelector, prerequisites *PrerequisiteChecker) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		templateID, err := request.RequireString("template_id")
 		if err != nil {
@@ -19,41 +21,65 @@ func NewTaskTemplateInstantiateHandler(repo contracts.TaskTemplateRepository, ta
 		parametersJSON := request.GetString("parameters", "")
 		var parameters map[string]string
 		if parametersJSON != "" {
-			if err := json.Unmarshal([]byte(parametersJSON), &parameters); err != nil {
+			if parameters, err = parseParameterValues(parametersJSON); err != nil {
 				return mcp.NewToolResultError("Invalid parameters JSON: " + err.Error()), nil
 			}
 		}
 
-		// Get the template first to validate parameters
-		template, err := repo.GetTemplate(templateID)
+		// Get the template first to validate parameters, pinned to a version if one is given
+		version := request.GetInt("version", 0)
+		template, err := repo.GetTemplateVersion(templateID, version)
 		if err != nil {
 			return mcp.NewToolResultError("Failed to get template: " + err.Error()), nil
 		}
 
-		// Validate parameters
-		if err := validateTemplateParameters(template, parameters); err != nil {
+		// Validate parameters, with defaults filled in for missing ones
+		parameters = template.ParametersWithDefaults(parameters)
+		if err := template.ValidateParameterValues(parameters); err != nil {
 			return mcp.NewToolResultError("Parameter validation failed: " + err.Error()), nil
 		}
 
+		// Check prerequisites before any task is created
+		unmet, notes := prerequisites.Check(template, parameters)
+		force := request.GetBool("force", false)
+		if len(unmet) > 0 && !force {
+			data, err := json.Marshal(unmet)
+			if err != nil {
+				return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("Unmet prerequisites, use 'force' to instantiate anyway: %s", data)), nil
+		}
+
 		// Instantiate the template
-		instance, err := repo.InstantiateTemplate(templateID, parameters)
+		instance, err := repo.InstantiateTemplateVersion(templateID, version, parameters)
 		if err != nil {
 			return mcp.NewToolResultError("Failed to instantiate template: " + err.Error()), nil
 		}
 
 		// Add the resolved tasks to the task queue
-		addedTasks, err := taskRepo.AddTasks(instance.Tasks)
+		addedTasks, err := taskRepo.AddTasks(queues.Queue(ctx), contracts.NewTasksFromContents(instance.Tasks))
 		if err != nil {
 			return mcp.NewToolResultError("Failed to add tasks from template: " + err.Error()), nil
 		}
 
+		if err := repo.RecordInstantiation(templateID); err != nil {
+			return mcp.NewToolResultError("Failed to record instantiation: " + err.Error()), nil
+		}
+
 		result := map[string]interface{}{
 			"message":     "Template instantiated successfully",
 			"template_id": templateID,
+			"version":     instance.Version,
 			"tasks_added": len(addedTasks),
 			"tasks":       addedTasks,
 			"parameters":  parameters,
 		}
+		if len(notes) > 0 {
+			result["prerequisite_notes"] = notes
+		}
+		if len(unmet) > 0 {
+			result["unmet_prerequisites"] = unmet
+		}
 
 		data, err := json.Marshal(result)
 		if err != nil {
@@ -0,0 +1,54 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// NewTaskTemplateRollbackHandler creates a handler for restoring a previous version of a template.
+// The restored version becomes a new version, so the rollback itself can be undone.
+func NewTaskTemplateRollbackHandler(repo contracts.TaskTemplateRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		templateID, err := request.RequireString("template_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing 'template_id' parameter: " + err.Error()), nil
+		}
+
+		version := request.GetInt("version", 0)
+		if version < 1 {
+			return mcp.NewToolResultError("Missing 'version' parameter: a version of at least 1 is required"), nil
+		}
+
+		template, err := repo.RollbackTemplate(templateID, version)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to roll back template: " + err.Error()), nil
+		}
+
+		result := map[string]interface{}{
+			"message":       "Template rolled back successfully",
+			"template_id":   template.ID,
+			"restored_from": version,
+			"version":       template.Version,
+			"updated_at":    template.UpdatedAt,
+		}
+
+		// Older versions may include templates that changed or no longer exist since
+		warnings := templateWarnings(template)
+		if err := validateIncludes(repo, template); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+		if len(warnings) > 0 {
+			result["warnings"] = warnings
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal result: " + err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
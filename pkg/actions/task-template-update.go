@@ -37,6 +37,9 @@ func NewTaskTemplateUpdateHandler(repo contracts.TaskTemplateRepository) func(co
 		if err := validateTemplate(&template); err != nil {
 			return mcp.NewToolResultError("Template validation failed: " + err.Error()), nil
 		}
+		if err := validateIncludes(repo, &template); err != nil {
+			return mcp.NewToolResultError("Template validation failed: " + err.Error()), nil
+		}
 
 		if err := repo.UpdateTemplate(&template); err != nil {
 			return mcp.NewToolResultError("Failed to update template: " + err.Error()), nil
@@ -45,8 +48,12 @@ func NewTaskTemplateUpdateHandler(repo contracts.TaskTemplateRepository) func(co
 		result := map[string]interface{}{
 			"message":     "Template updated successfully",
 			"template_id": template.ID,
+			"version":     template.Version,
 			"updated_at":  template.UpdatedAt,
 		}
+		if warnings := templateWarnings(&template); len(warnings) > 0 {
+			result["warnings"] = warnings
+		}
 
 		data, err := json.Marshal(result)
 		if err != nil {
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
 	"github.com/mstrehse/mcp-brain/pkg/repositories/task"
 )
 
@@ -17,7 +18,7 @@ func TestTasksAddHandler(t *testing.T) {
 	}
 	defer func() { _ = repo.Close() }()
 
-	handler := NewTasksAddHandler(repo)
+	handler := NewTasksAddHandler(repo, NewQueueSelector())
 
 	t.Run("successful add tasks", func(t *testing.T) {
 		request := mcp.CallToolRequest{
@@ -71,6 +72,62 @@ func TestTasksAddHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("successful add tasks with dependencies", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "tasks-add",
+				Arguments: map[string]interface{}{
+					"tasks": `[{"content": "Run migrations"}, {"content": "Update fixtures", "depends_on": ["#1"]}]`,
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if result.IsError {
+			t.Fatal("Handler returned error result")
+		}
+
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+
+		var taskResult struct {
+			Tasks []contracts.Task `json:"tasks"`
+		}
+		if err := json.Unmarshal([]byte(textContent.Text), &taskResult); err != nil {
+			t.Fatalf("Result is not valid JSON: %v", err)
+		}
+
+		if len(taskResult.Tasks) != 2 || len(taskResult.Tasks[1].BlockedBy) != 1 || taskResult.Tasks[1].BlockedBy[0] != taskResult.Tasks[0].ID {
+			t.Errorf("Expected second task to be blocked by the first, got: %s", textContent.Text)
+		}
+	})
+
+	t.Run("dependency cycle", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "tasks-add",
+				Arguments: map[string]interface{}{
+					"tasks": `[{"content": "A", "depends_on": ["#2"]}, {"content": "B", "depends_on": ["#1"]}]`,
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if !result.IsError {
+			t.Error("Expected error result for dependency cycle")
+		}
+	})
+
 	t.Run("missing contents parameter", func(t *testing.T) {
 		request := mcp.CallToolRequest{
 			Params: mcp.CallToolParams{
@@ -118,10 +175,10 @@ func TestTaskGetHandler(t *testing.T) {
 	}
 	defer func() { _ = repo.Close() }()
 
-	handler := NewTaskGetHandler(repo)
+	handler := NewTaskGetHandler(repo, NewQueueSelector())
 
 	// Setup test data
-	tasks, err := repo.AddTasks([]string{"Test task 1", "Test task 2"})
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Test task 1", "Test task 2"}))
 	if err != nil {
 		t.Fatalf("Failed to setup test data: %v", err)
 	}
@@ -186,7 +243,7 @@ func TestTaskGetHandler(t *testing.T) {
 	t.Run("get task when queue is empty", func(t *testing.T) {
 		// Get all remaining tasks to empty the queue
 		for {
-			task, err := repo.GetTask()
+			task, err := repo.GetTask(contracts.DefaultQueue)
 			if err != nil || task == nil {
 				break
 			}
@@ -219,3 +276,490 @@ func TestTaskGetHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestTaskCompleteHandler(t *testing.T) {
+	baseDir := t.TempDir()
+	repo, err := task.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	handler := NewTaskCompleteHandler(repo, NewQueueSelector())
+
+	// Setup test data
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Test task 1"}))
+	if err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	t.Run("successful complete", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "task-complete",
+				Arguments: map[string]interface{}{
+					"task_id": tasks[0].ID,
+					"note":    "Done and dusted",
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if result.IsError {
+			t.Fatal("Handler returned error result")
+		}
+
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+
+		var completeResult struct {
+			Task contracts.Task `json:"task"`
+		}
+		if err := json.Unmarshal([]byte(textContent.Text), &completeResult); err != nil {
+			t.Fatalf("Result is not valid JSON: %v", err)
+		}
+
+		if completeResult.Task.Status != contracts.TaskStatusDone {
+			t.Errorf("Expected status done, got %s", completeResult.Task.Status)
+		}
+		if completeResult.Task.Outcome != "Done and dusted" {
+			t.Errorf("Expected outcome note, got %q", completeResult.Task.Outcome)
+		}
+	})
+
+	t.Run("already completed task", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "task-complete",
+				Arguments: map[string]interface{}{
+					"task_id": tasks[0].ID,
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if !result.IsError {
+			t.Error("Expected error result for already completed task")
+		}
+	})
+
+	t.Run("missing task_id parameter", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "task-complete",
+				Arguments: map[string]interface{}{},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if !result.IsError {
+			t.Error("Expected error result for missing task_id")
+		}
+	})
+}
+
+func TestTaskFailHandler(t *testing.T) {
+	baseDir := t.TempDir()
+	repo, err := task.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	handler := NewTaskFailHandler(repo, NewQueueSelector())
+
+	// Setup test data
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Test task 1"}))
+	if err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	t.Run("missing note parameter", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "task-fail",
+				Arguments: map[string]interface{}{
+					"task_id": tasks[0].ID,
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if !result.IsError {
+			t.Error("Expected error result for missing note")
+		}
+	})
+
+	t.Run("successful fail", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "task-fail",
+				Arguments: map[string]interface{}{
+					"task_id": tasks[0].ID,
+					"note":    "Build server is down",
+				},
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		if result.IsError {
+			t.Fatal("Handler returned error result")
+		}
+
+		all, err := repo.GetAllTasks(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get tasks: %v", err)
+		}
+		if all[0].Status != contracts.TaskStatusFailed || all[0].Outcome != "Build server is down" {
+			t.Errorf("Expected failed task with note, got %+v", all[0])
+		}
+	})
+}
+
+func TestTaskReleaseHandler(t *testing.T) {
+	baseDir := t.TempDir()
+	repo, err := task.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	handler := NewTaskReleaseHandler(repo, NewQueueSelector())
+
+	// Setup test data
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Test task 1"}))
+	if err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "task-release",
+			Arguments: map[string]interface{}{
+				"task_id": tasks[0].ID,
+			},
+		},
+	}
+
+	t.Run("pending task", func(t *testing.T) {
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if !result.IsError {
+			t.Error("Expected error result for a task that isn't in progress")
+		}
+	})
+
+	t.Run("successful release", func(t *testing.T) {
+		if _, err := repo.GetTask(contracts.DefaultQueue); err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if result.IsError {
+			t.Fatal("Handler returned error result")
+		}
+
+		count, err := repo.GetTaskCount(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to count tasks: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected the task to be pending again, got %d pending tasks", count)
+		}
+	})
+}
+
+func TestTaskPeekHandler(t *testing.T) {
+	baseDir := t.TempDir()
+	repo, err := task.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	handler := NewTaskPeekHandler(repo, NewQueueSelector())
+
+	// Setup test data
+	if _, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Test task 1", "Test task 2"})); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	t.Run("peek does not consume", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "task-peek",
+				Arguments: map[string]interface{}{},
+			},
+		}
+
+		for i := 0; i < 2; i++ {
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+
+			textContent, ok := mcp.AsTextContent(result.Content[0])
+			if !ok {
+				t.Fatal("Expected text content")
+			}
+
+			var peeked contracts.Task
+			if err := json.Unmarshal([]byte(textContent.Text), &peeked); err != nil {
+				t.Fatalf("Result is not valid JSON: %v", err)
+			}
+			if peeked.Content != "Test task 1" || peeked.Status != contracts.TaskStatusPending {
+				t.Errorf("Expected pending 'Test task 1', got %+v", peeked)
+			}
+		}
+
+		count, err := repo.GetTaskCount(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get task count: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Expected 2 pending tasks, got %d", count)
+		}
+	})
+}
+
+func TestTasksListHandler(t *testing.T) {
+	baseDir := t.TempDir()
+	repo, err := task.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	handler := NewTasksListHandler(repo, NewQueueSelector())
+
+	// Setup test data
+	if _, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Task 1", "Task 2", "Task 3", "Task 4"})); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+	if _, err := repo.GetTask(contracts.DefaultQueue); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	list := func(t *testing.T, arguments map[string]interface{}) (*mcp.CallToolResult, map[string]interface{}) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "tasks-list",
+				Arguments: arguments,
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if result.IsError {
+			return result, nil
+		}
+
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+
+		var listResult map[string]interface{}
+		if err := json.Unmarshal([]byte(textContent.Text), &listResult); err != nil {
+			t.Fatalf("Result is not valid JSON: %v", err)
+		}
+		return result, listResult
+	}
+
+	t.Run("list all tasks", func(t *testing.T) {
+		_, listResult := list(t, map[string]interface{}{})
+		if total, ok := listResult["total"].(float64); !ok || int(total) != 4 {
+			t.Errorf("Expected total 4, got: %v", listResult["total"])
+		}
+	})
+
+	t.Run("filter by status", func(t *testing.T) {
+		_, listResult := list(t, map[string]interface{}{"status": "in_progress"})
+		tasks, _ := listResult["tasks"].([]interface{})
+		if len(tasks) != 1 {
+			t.Fatalf("Expected 1 task in progress, got %d", len(tasks))
+		}
+		if content := tasks[0].(map[string]interface{})["content"]; content != "Task 1" {
+			t.Errorf("Expected 'Task 1', got %v", content)
+		}
+	})
+
+	t.Run("paginate", func(t *testing.T) {
+		_, listResult := list(t, map[string]interface{}{"offset": 1, "limit": 2})
+		tasks, _ := listResult["tasks"].([]interface{})
+		if len(tasks) != 2 {
+			t.Fatalf("Expected 2 tasks, got %d", len(tasks))
+		}
+		if content := tasks[0].(map[string]interface{})["content"]; content != "Task 2" {
+			t.Errorf("Expected 'Task 2', got %v", content)
+		}
+
+		_, listResult = list(t, map[string]interface{}{"offset": 10})
+		if tasks, _ := listResult["tasks"].([]interface{}); len(tasks) != 0 {
+			t.Errorf("Expected empty page, got %d tasks", len(tasks))
+		}
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		result, _ := list(t, map[string]interface{}{"status": "unknown"})
+		if !result.IsError {
+			t.Error("Expected error result for invalid status")
+		}
+	})
+
+	t.Run("listing does not consume", func(t *testing.T) {
+		count, err := repo.GetTaskCount(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get task count: %v", err)
+		}
+		if count != 3 {
+			t.Errorf("Expected 3 pending tasks, got %d", count)
+		}
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		all, err := repo.GetAllTasks(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get all tasks: %v", err)
+		}
+		addHandler := NewTasksAddHandler(repo, NewQueueSelector())
+		result, err := addHandler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "tasks-add",
+				Arguments: map[string]interface{}{
+					"contents":  []interface{}{"Subtask A", "Subtask B"},
+					"parent_id": all[0].ID,
+				},
+			},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("Failed to add subtasks: %v %v", err, result)
+		}
+
+		_, listResult := list(t, map[string]interface{}{"tree": true})
+		if total, ok := listResult["total"].(float64); !ok || int(total) != 4 {
+			t.Errorf("Expected 4 top-level tasks, got: %v", listResult["total"])
+		}
+		tasks, _ := listResult["tasks"].([]interface{})
+		subtasks, _ := tasks[0].(map[string]interface{})["subtasks"].([]interface{})
+		if len(subtasks) != 2 {
+			t.Fatalf("Expected 2 subtasks below the first task, got %d", len(subtasks))
+		}
+		if content := subtasks[0].(map[string]interface{})["content"]; content != "Subtask A" {
+			t.Errorf("Expected 'Subtask A', got %v", content)
+		}
+
+		_, listResult = list(t, map[string]interface{}{})
+		if total, ok := listResult["total"].(float64); !ok || int(total) != 6 {
+			t.Errorf("Expected 6 tasks in flat listing, got: %v", listResult["total"])
+		}
+	})
+}
+
+func TestTaskMoveHandler(t *testing.T) {
+	repo, err := task.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	queues := NewQueueSelector()
+	addHandler := NewTasksAddHandler(repo, queues)
+	moveHandler := NewTaskMoveHandler(repo, queues)
+	peekHandler := NewTaskPeekHandler(repo, queues)
+
+	call := func(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) *mcp.CallToolResult {
+		result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		return result
+	}
+	peek := func(t *testing.T) string {
+		textContent, _ := mcp.AsTextContent(call(t, peekHandler, map[string]interface{}{}).Content[0])
+		var peeked contracts.Task
+		if err := json.Unmarshal([]byte(textContent.Text), &peeked); err != nil {
+			t.Fatalf("Result is not valid JSON: %v", err)
+		}
+		return peeked.Content
+	}
+
+	call(t, addHandler, map[string]interface{}{"contents": []interface{}{"Refactor A", "Refactor B"}})
+
+	t.Run("insert at front", func(t *testing.T) {
+		result := call(t, addHandler, map[string]interface{}{"contents": []interface{}{"Hotfix"}, "front": true})
+		if result.IsError {
+			t.Fatalf("Expected success, got %v", result.Content)
+		}
+		if next := peek(t); next != "Hotfix" {
+			t.Errorf("Expected 'Hotfix' next, got %q", next)
+		}
+	})
+
+	t.Run("priority beats queue order", func(t *testing.T) {
+		call(t, addHandler, map[string]interface{}{"contents": []interface{}{"Outage"}, "priority": 5})
+		if next := peek(t); next != "Outage" {
+			t.Errorf("Expected 'Outage' next, got %q", next)
+		}
+	})
+
+	t.Run("move task", func(t *testing.T) {
+		all, err := repo.GetAllTasks(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get all tasks: %v", err)
+		}
+		// Queue order is Hotfix, Refactor A, Refactor B, Outage
+		result := call(t, moveHandler, map[string]interface{}{"task_id": all[2].ID, "position": 1})
+		if result.IsError {
+			t.Fatalf("Expected success, got %v", result.Content)
+		}
+		all, err = repo.GetAllTasks(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get all tasks: %v", err)
+		}
+		if all[0].Content != "Refactor B" {
+			t.Errorf("Expected 'Refactor B' first, got %q", all[0].Content)
+		}
+	})
+
+	t.Run("move requires a placement", func(t *testing.T) {
+		if result := call(t, moveHandler, map[string]interface{}{"task_id": "task-1"}); !result.IsError {
+			t.Error("Expected error result without placement")
+		}
+	})
+
+	t.Run("placement cannot be combined with parent_id", func(t *testing.T) {
+		result := call(t, addHandler, map[string]interface{}{"contents": []interface{}{"Sub"}, "parent_id": "task-1", "front": true})
+		if !result.IsError {
+			t.Error("Expected error result for placement with parent_id")
+		}
+	})
+}
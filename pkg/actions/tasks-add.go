@@ -9,18 +9,44 @@ import (
 )
 
 // NewTasksAddHandler creates a handler for adding tasks with dependency injection
-func NewTasksAddHandler(repo contracts.TaskRepository) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func NewTasksAddHandler(repo contracts.TaskRepository, queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		contents, err := request.RequireStringSlice("contents")
-		if err != nil {
-			return mcp.NewToolResultError("Missing 'contents' parameter: " + err.Error()), nil
+		var newTasks []contracts.NewTask
+
+		if tasksJSON := request.GetString("tasks", ""); tasksJSON != "" {
+			if _, hasContents := request.GetArguments()["contents"]; hasContents {
+				return mcp.NewToolResultError("Use either 'contents' or 'tasks', not both"), nil
+			}
+			if err := json.Unmarshal([]byte(tasksJSON), &newTasks); err != nil {
+				return mcp.NewToolResultError("Invalid tasks JSON: " + err.Error()), nil
+			}
+		} else {
+			contents, err := request.RequireStringSlice("contents")
+			if err != nil {
+				return mcp.NewToolResultError("Missing 'contents' parameter: " + err.Error()), nil
+			}
+			newTasks = contracts.NewTasksFromContents(contents)
+			for i := range newTasks {
+				newTasks[i].Priority = request.GetInt("priority", 0)
+			}
 		}
 
-		if len(contents) == 0 {
+		if len(newTasks) == 0 {
 			return mcp.NewToolResultError("Contents array cannot be empty"), nil
 		}
 
-		tasks, err := repo.AddTasks(contents)
+		placement := taskPlacementFromRequest(request)
+
+		var tasks []*contracts.Task
+		var err error
+		if parentID := request.GetString("parent_id", ""); parentID != "" {
+			if placement != (contracts.TaskPlacement{}) {
+				return mcp.NewToolResultError("Subtasks are always added below their parent, 'front', 'after' and 'position' cannot be used with 'parent_id'"), nil
+			}
+			tasks, err = repo.AddSubtasks(queues.Queue(ctx), parentID, newTasks)
+		} else {
+			tasks, err = repo.InsertTasks(queues.Queue(ctx), newTasks, placement)
+		}
 		if err != nil {
 			return mcp.NewToolResultError("Failed to add tasks: " + err.Error()), nil
 		}
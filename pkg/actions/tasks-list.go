@@ -0,0 +1,111 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+const (
+	// defaultTasksListLimit is the page size used when no limit is given
+	defaultTasksListLimit = 50
+
+	// taskStatusBlocked filters for pending tasks that wait for unfinished dependencies
+	taskStatusBlocked contracts.TaskStatus = "blocked"
+)
+
+// taskNode is a task together with its subtasks, used for tree listings
+type taskNode struct {
+	*contracts.Task
+	Subtasks []*taskNode `json:"subtasks,omitempty"`
+}
+
+// buildTaskTree nests tasks below their parents. Tasks whose parent is not listed become roots.
+func buildTaskTree(tasks []*contracts.Task) []*taskNode {
+	nodes := make(map[string]*taskNode, len(tasks))
+	for _, task := range tasks {
+		nodes[task.ID] = &taskNode{Task: task}
+	}
+
+	roots := []*taskNode{}
+	for _, task := range tasks {
+		node := nodes[task.ID]
+		if parent, exists := nodes[task.ParentID]; exists && task.ParentID != "" {
+			parent.Subtasks = append(parent.Subtasks, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+// NewTasksListHandler creates a handler for listing tasks without consuming them
+func NewTasksListHandler(repo contracts.TaskRepository, queues *QueueSelector) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		status := contracts.TaskStatus(request.GetString("status", ""))
+		switch status {
+		case "", taskStatusBlocked, contracts.TaskStatusPending, contracts.TaskStatusInProgress, contracts.TaskStatusDone, contracts.TaskStatusFailed, contracts.TaskStatusCancelled:
+		default:
+			return mcp.NewToolResultError("Invalid 'status' parameter: " + string(status)), nil
+		}
+
+		offset := request.GetInt("offset", 0)
+		if offset < 0 {
+			return mcp.NewToolResultError("'offset' must not be negative"), nil
+		}
+		limit := request.GetInt("limit", defaultTasksListLimit)
+		if limit <= 0 {
+			return mcp.NewToolResultError("'limit' must be positive"), nil
+		}
+
+		tasks, err := repo.GetAllTasks(queues.Queue(ctx))
+		if err != nil {
+			return mcp.NewToolResultError("Failed to list tasks: " + err.Error()), nil
+		}
+
+		filtered := []*contracts.Task{}
+		for _, task := range tasks {
+			blocked := len(task.BlockedBy) > 0
+			if status == "" || task.Status == status || (status == taskStatusBlocked && blocked) {
+				filtered = append(filtered, task)
+			}
+		}
+
+		// In tree mode, pagination applies to the top-level tasks
+		var items []interface{}
+		if request.GetBool("tree", false) {
+			for _, node := range buildTaskTree(filtered) {
+				items = append(items, node)
+			}
+		} else {
+			for _, task := range filtered {
+				items = append(items, task)
+			}
+		}
+
+		page := []interface{}{}
+		if offset < len(items) {
+			end := offset + limit
+			if end > len(items) {
+				end = len(items)
+			}
+			page = items[offset:end]
+		}
+
+		result := map[string]interface{}{
+			"tasks":  page,
+			"total":  len(items),
+			"offset": offset,
+			"limit":  limit,
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal tasks result: " + err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -0,0 +1,149 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// PrerequisiteChecker checks the prerequisites of templates against the brain, the workspace and the PATH
+type PrerequisiteChecker struct {
+	knowledge    contracts.KnowledgeRepository
+	templates    contracts.TaskTemplateRepository
+	workspaceDir string
+}
+
+// NewPrerequisiteChecker creates a checker that looks up workspace files relative to workspaceDir
+func NewPrerequisiteChecker(knowledge contracts.KnowledgeRepository, templates contracts.TaskTemplateRepository, workspaceDir string) *PrerequisiteChecker {
+	return &PrerequisiteChecker{
+		knowledge:    knowledge,
+		templates:    templates,
+		workspaceDir: workspaceDir,
+	}
+}
+
+// UnmetPrerequisite is a prerequisite that doesn't hold, with the reason why
+type UnmetPrerequisite struct {
+	Prerequisite string `json:"prerequisite"`
+	Reason       string `json:"reason"`
+	Template     string `json:"template,omitempty"` // the included template the prerequisite belongs to
+}
+
+// Check evaluates the prerequisites of a template with resolved parameters, including the
+// prerequisites of the templates it includes with their mapped parameters.
+// It returns every unmet prerequisite and the notes, which can't be checked automatically.
+func (c *PrerequisiteChecker) Check(template *contracts.TaskTemplate, parameters map[string]string) ([]UnmetPrerequisite, []string) {
+	unmet := []UnmetPrerequisite{}
+	notes := []string{}
+	seen := make(map[string]bool)
+
+	var visit func(current *contracts.TaskTemplate, parameters map[string]string, chain []string)
+	visit = func(current *contracts.TaskTemplate, parameters map[string]string, chain []string) {
+		for _, prerequisite := range current.Prerequisites {
+			prerequisite = prerequisite.Resolve(parameters)
+
+			// Templates included several times share their prerequisites
+			key := prerequisite.String()
+			if prerequisite.Type == contracts.PrerequisiteTypeNote {
+				key = prerequisite.Description
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if prerequisite.Type == contracts.PrerequisiteTypeNote {
+				notes = append(notes, prerequisite.Description)
+				continue
+			}
+			if err := c.check(prerequisite); err != nil {
+				entry := UnmetPrerequisite{Prerequisite: prerequisite.String(), Reason: err.Error()}
+				if current != template {
+					entry.Template = current.ID
+				}
+				unmet = append(unmet, entry)
+			}
+		}
+
+		for _, task := range current.Tasks {
+			if task.Include == "" {
+				continue
+			}
+			// Broken includes are reported when the template is instantiated
+			if containsValue(chain, task.Include) || len(chain) > contracts.MaxTemplateIncludeDepth {
+				continue
+			}
+			if applies, err := task.Applies(parameters); err != nil || !applies {
+				continue
+			}
+			included, err := c.templates.GetTemplate(task.Include)
+			if err != nil {
+				continue
+			}
+
+			// Loops include the template once for each item
+			iterations := []map[string]string{parameters}
+			if task.ForEach != "" {
+				iterations = nil
+				for _, item := range contracts.SplitListValue(parameters[task.ForEach]) {
+					itemParameters := make(map[string]string, len(parameters)+1)
+					for name, value := range parameters {
+						itemParameters[name] = value
+					}
+					itemParameters[contracts.TemplateItemPlaceholder] = item
+					iterations = append(iterations, itemParameters)
+				}
+			}
+			for _, iterationParameters := range iterations {
+				values := make(map[string]string, len(task.With))
+				for name, value := range task.With {
+					values[name] = contracts.ResolvePlaceholders(value, iterationParameters)
+				}
+				visit(included, included.ParametersWithDefaults(values), append(append([]string{}, chain...), included.ID))
+			}
+		}
+	}
+	visit(template, parameters, []string{template.ID})
+
+	return unmet, notes
+}
+
+// check returns why a single prerequisite doesn't hold, or nil if it does
+func (c *PrerequisiteChecker) check(prerequisite contracts.Prerequisite) error {
+	if err := prerequisite.Validate(); err != nil {
+		return err
+	}
+
+	switch prerequisite.Type {
+	case contracts.PrerequisiteTypeMemory:
+		if _, err := c.knowledge.Read(prerequisite.Project, prerequisite.Path); err != nil {
+			return err
+		}
+	case contracts.PrerequisiteTypeFile:
+		if !filepath.IsLocal(prerequisite.Path) {
+			return fmt.Errorf("path '%s' is outside of the workspace", prerequisite.Path)
+		}
+		if _, err := os.Stat(filepath.Join(c.workspaceDir, prerequisite.Path)); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("file not found: %s", prerequisite.Path)
+			}
+			return fmt.Errorf("failed to check file: %w", err)
+		}
+	case contracts.PrerequisiteTypeCommand:
+		if _, err := exec.LookPath(prerequisite.Command); err != nil {
+			return fmt.Errorf("command not found: %s", prerequisite.Command)
+		}
+	case contracts.PrerequisiteTypeTemplate:
+		instantiated, err := c.templates.LastInstantiation(prerequisite.Template)
+		if err != nil {
+			return err
+		}
+		if instantiated == nil {
+			return fmt.Errorf("template %s has not been instantiated yet", prerequisite.Template)
+		}
+	}
+	return nil
+}
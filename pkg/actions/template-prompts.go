@@ -0,0 +1,150 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// TemplatePromptRepository wraps a template repository and keeps an MCP prompt registered for every template.
+// Creating, updating, rolling back or deleting templates through it refreshes the prompts,
+// which notifies clients with prompts/list_changed.
+type TemplatePromptRepository struct {
+	contracts.TaskTemplateRepository
+	server *server.MCPServer
+}
+
+// NewTemplatePromptRepository creates a repository that mirrors the templates of repo as prompts of s
+func NewTemplatePromptRepository(repo contracts.TaskTemplateRepository, s *server.MCPServer) *TemplatePromptRepository {
+	return &TemplatePromptRepository{
+		TaskTemplateRepository: repo,
+		server:                 s,
+	}
+}
+
+// CreateTemplate creates a new task template and registers its prompt
+func (r *TemplatePromptRepository) CreateTemplate(template *contracts.TaskTemplate) error {
+	if err := r.TaskTemplateRepository.CreateTemplate(template); err != nil {
+		return err
+	}
+	return r.RefreshPrompts()
+}
+
+// UpdateTemplate updates an existing template and its prompt
+func (r *TemplatePromptRepository) UpdateTemplate(template *contracts.TaskTemplate) error {
+	if err := r.TaskTemplateRepository.UpdateTemplate(template); err != nil {
+		return err
+	}
+	return r.RefreshPrompts()
+}
+
+// RollbackTemplate restores a previous version of a template and updates its prompt
+func (r *TemplatePromptRepository) RollbackTemplate(id string, version int) (*contracts.TaskTemplate, error) {
+	template, err := r.TaskTemplateRepository.RollbackTemplate(id, version)
+	if err != nil {
+		return nil, err
+	}
+	return template, r.RefreshPrompts()
+}
+
+// DeleteTemplate deletes a template and removes its prompt
+func (r *TemplatePromptRepository) DeleteTemplate(id string) error {
+	if err := r.TaskTemplateRepository.DeleteTemplate(id); err != nil {
+		return err
+	}
+	return r.RefreshPrompts()
+}
+
+// RefreshPrompts replaces the prompts of the server with one prompt per stored template
+func (r *TemplatePromptRepository) RefreshPrompts() error {
+	templates, err := r.ListTemplates()
+	if err != nil {
+		return fmt.Errorf("failed to list templates for prompts: %w", err)
+	}
+
+	prompts := make([]server.ServerPrompt, 0, len(templates))
+	for _, template := range templates {
+		prompts = append(prompts, server.ServerPrompt{
+			Prompt:  templatePrompt(template),
+			Handler: r.promptHandler(template.ID),
+		})
+	}
+	r.server.SetPrompts(prompts...)
+
+	return nil
+}
+
+// templatePrompt describes a template as a prompt whose arguments are the template parameters
+func templatePrompt(template *contracts.TaskTemplate) mcp.Prompt {
+	options := []mcp.PromptOption{
+		mcp.WithPromptDescription(fmt.Sprintf("%s: %s", template.Name, template.Description)),
+	}
+	for _, name := range sortedParameterNames(template.Parameters) {
+		param := template.Parameters[name]
+		argumentOptions := []mcp.ArgumentOption{mcp.ArgumentDescription(parameterDescription(param))}
+		if param.Required && param.Default == "" {
+			argumentOptions = append(argumentOptions, mcp.RequiredArgument())
+		}
+		options = append(options, mcp.WithArgument(name, argumentOptions...))
+	}
+	return mcp.NewPrompt(template.ID, options...)
+}
+
+// parameterDescription explains a parameter to users filling in a prompt argument
+func parameterDescription(param contracts.Parameter) string {
+	parts := []string{}
+	if param.Description != "" {
+		parts = append(parts, param.Description)
+	}
+	if param.Type != "" && param.Type != contracts.ParameterTypeString {
+		parts = append(parts, "type: "+param.Type)
+	}
+	if len(param.Values) > 0 {
+		parts = append(parts, "one of: "+strings.Join(param.Values, ", "))
+	}
+	if param.Default != "" {
+		parts = append(parts, "default: "+param.Default)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// promptHandler resolves a template with the prompt arguments into instructions for the agent
+func (r *TemplatePromptRepository) promptHandler(templateID string) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		template, err := r.GetTemplate(templateID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get template: %w", err)
+		}
+
+		parameters := template.ParametersWithDefaults(request.Params.Arguments)
+		if err := template.ValidateParameterValues(parameters); err != nil {
+			return nil, fmt.Errorf("parameter validation failed: %w", err)
+		}
+
+		instance, err := r.InstantiateTemplate(templateID, parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to instantiate template: %w", err)
+		}
+
+		parametersJSON, err := json.Marshal(instance.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal parameters: %w", err)
+		}
+
+		var text strings.Builder
+		fmt.Fprintf(&text, "Work through the task template '%s': %s\n\n", template.Name, template.Description)
+		fmt.Fprintf(&text, "Call 'task-template-instantiate' with template_id %q and parameters %s to add these tasks to the task queue, then work through them with 'task-get':\n", template.ID, parametersJSON)
+		for i, task := range instance.Tasks {
+			fmt.Fprintf(&text, "%d. %s\n", i+1, task)
+		}
+
+		return mcp.NewGetPromptResult(template.Name, []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text.String())),
+		}), nil
+	}
+}
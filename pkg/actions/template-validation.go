@@ -1,7 +1,11 @@
 package actions
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/mstrehse/mcp-brain/pkg/contracts"
@@ -19,50 +23,255 @@ func validateTemplate(template *contracts.TaskTemplate) error {
 		return fmt.Errorf("template must have at least one task")
 	}
 
-	// Validate parameters
-	for paramName, param := range template.Parameters {
+	// Validate parameters, reporting every broken one at once
+	var problems []string
+	for _, paramName := range sortedParameterNames(template.Parameters) {
 		if paramName == "" {
-			return fmt.Errorf("parameter name cannot be empty")
+			problems = append(problems, "parameter name cannot be empty")
+			continue
 		}
-		if param.Type == "" {
-			return fmt.Errorf("parameter '%s' must have a type", paramName)
+		if err := validateParameterDefinition(template.Parameters[paramName]); err != nil {
+			problems = append(problems, fmt.Sprintf("parameter '%s': %v", paramName, err))
 		}
-		if param.Type == "enum" && len(param.Values) == 0 {
-			return fmt.Errorf("enum parameter '%s' must have values", paramName)
+	}
+
+	// Every referenced parameter needs a declaration, or agents would get raw ${name} text
+	for _, name := range template.ReferencedParameters() {
+		if _, declared := template.Parameters[name]; !declared {
+			problems = append(problems, fmt.Sprintf("parameter '%s' is used in tasks or prerequisites but not declared in parameters", name))
+		}
+	}
+
+	// Conditions have to parse and loops have to run over lists
+	for i, task := range template.Tasks {
+		if (task.Content == "") == (task.Include == "") {
+			problems = append(problems, fmt.Sprintf("task %d: use either content or include", i+1))
+		}
+		if len(task.With) > 0 && task.Include == "" {
+			problems = append(problems, fmt.Sprintf("task %d: with only applies to include", i+1))
+		}
+		if task.When != "" {
+			if _, _, _, err := task.Condition(); err != nil {
+				problems = append(problems, fmt.Sprintf("task %d: %v", i+1, err))
+			}
+		}
+		if param, declared := template.Parameters[task.ForEach]; task.ForEach != "" && declared && param.Type != contracts.ParameterTypeList {
+			problems = append(problems, fmt.Sprintf("task %d: for_each parameter '%s' must be of type list", i+1, task.ForEach))
 		}
 	}
 
+	for i, prerequisite := range template.Prerequisites {
+		if err := prerequisite.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("prerequisite %d: %v", i+1, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
 	return nil
 }
 
-// validateTemplateParameters validates that provided parameters match template requirements
-func validateTemplateParameters(template *contracts.TaskTemplate, parameters map[string]string) error {
-	// Check required parameters
-	for paramName, param := range template.Parameters {
-		if param.Required {
-			if value, exists := parameters[paramName]; !exists || value == "" {
-				return fmt.Errorf("required parameter '%s' is missing", paramName)
+// validateIncludes checks that included templates exist, accept the mapped parameters, get their
+// required parameters and don't include each other in a cycle or too deeply
+func validateIncludes(repo contracts.TaskTemplateRepository, template *contracts.TaskTemplate) error {
+	var problems []string
+
+	var visit func(current *contracts.TaskTemplate, chain []string)
+	visit = func(current *contracts.TaskTemplate, chain []string) {
+		for _, task := range current.Tasks {
+			if task.Include == "" {
+				continue
+			}
+
+			if containsValue(chain, task.Include) {
+				problems = append(problems, fmt.Sprintf("include cycle: %s -> %s", strings.Join(chain, " -> "), task.Include))
+				continue
+			}
+			if len(chain) > contracts.MaxTemplateIncludeDepth {
+				problems = append(problems, fmt.Sprintf("templates are included more than %d levels deep: %s", contracts.MaxTemplateIncludeDepth, strings.Join(chain, " -> ")))
+				continue
 			}
-		}
-	}
 
-	// Validate enum parameters
-	for paramName, value := range parameters {
-		if param, exists := template.Parameters[paramName]; exists {
-			if param.Type == "enum" && len(param.Values) > 0 {
-				valid := false
-				for _, validValue := range param.Values {
-					if value == validValue {
-						valid = true
-						break
+			// The template being saved replaces its stored version
+			included := template
+			if task.Include != template.ID {
+				var err error
+				if included, err = repo.GetTemplate(task.Include); err != nil {
+					problems = append(problems, fmt.Sprintf("included template '%s' not found", task.Include))
+					continue
+				}
+			}
+
+			// Only the includes of the template being saved are checked in detail
+			if current == template {
+				for _, name := range sortedKeys(task.With) {
+					if _, declared := included.Parameters[name]; !declared {
+						problems = append(problems, fmt.Sprintf("included template '%s' has no parameter '%s'", task.Include, name))
 					}
 				}
-				if !valid {
-					return fmt.Errorf("parameter '%s' must be one of: %s", paramName, strings.Join(param.Values, ", "))
+				for _, name := range sortedParameterNames(included.Parameters) {
+					param := included.Parameters[name]
+					if _, mapped := task.With[name]; param.Required && param.Default == "" && !mapped {
+						problems = append(problems, fmt.Sprintf("included template '%s' requires parameter '%s'", task.Include, name))
+					}
 				}
 			}
+
+			visit(included, append(append([]string{}, chain...), included.ID))
+		}
+	}
+	visit(template, []string{template.ID})
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// includingTemplates returns the IDs of all templates that include the template with the given ID
+func includingTemplates(repo contracts.TaskTemplateRepository, id string) ([]string, error) {
+	templates, err := repo.ListTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	including := []string{}
+	for _, template := range templates {
+		for _, task := range template.Tasks {
+			if task.Include == id && template.ID != id {
+				including = append(including, template.ID)
+				break
+			}
+		}
+	}
+	return including, nil
+}
+
+// templateWarnings returns hints about a valid template that are worth fixing, like unused parameters
+func templateWarnings(template *contracts.TaskTemplate) []string {
+	used := make(map[string]bool)
+	for _, name := range template.ReferencedParameters() {
+		used[name] = true
+	}
+
+	warnings := []string{}
+	for _, paramName := range sortedParameterNames(template.Parameters) {
+		if !used[paramName] {
+			warnings = append(warnings, fmt.Sprintf("parameter '%s' is declared but not used in any task", paramName))
+		}
+	}
+	return warnings
+}
+
+// validateParameterDefinition checks that the constraints of a parameter fit its type and that its default conforms
+func validateParameterDefinition(param contracts.Parameter) error {
+	switch param.Type {
+	case "":
+		return fmt.Errorf("must have a type")
+	case contracts.ParameterTypeString, contracts.ParameterTypeEnum, contracts.ParameterTypeNumber, contracts.ParameterTypeBoolean, contracts.ParameterTypeList:
+	default:
+		return fmt.Errorf("unknown type '%s', use string, enum, number, boolean or list", param.Type)
+	}
+
+	if param.Type == contracts.ParameterTypeEnum && len(param.Values) == 0 {
+		return fmt.Errorf("enum parameter must have values")
+	}
+	if (param.Min != nil || param.Max != nil) && param.Type != contracts.ParameterTypeNumber {
+		return fmt.Errorf("min and max only apply to number parameters")
+	}
+	if param.Min != nil && param.Max != nil && *param.Min > *param.Max {
+		return fmt.Errorf("min %s is greater than max %s", formatNumber(*param.Min), formatNumber(*param.Max))
+	}
+	if (param.Pattern != "" || param.MinLength != nil || param.MaxLength != nil) && param.Type != contracts.ParameterTypeString && param.Type != contracts.ParameterTypeList {
+		return fmt.Errorf("pattern, min_length and max_length only apply to string and list parameters")
+	}
+	if param.Pattern != "" {
+		if _, err := regexp.Compile(param.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+	}
+	if (param.MinLength != nil && *param.MinLength < 0) || (param.MaxLength != nil && *param.MaxLength < 0) {
+		return fmt.Errorf("min_length and max_length must not be negative")
+	}
+	if param.MinLength != nil && param.MaxLength != nil && *param.MinLength > *param.MaxLength {
+		return fmt.Errorf("min_length %d is greater than max_length %d", *param.MinLength, *param.MaxLength)
+	}
+
+	if param.Default != "" {
+		if err := param.ValidateValue(param.Default); err != nil {
+			return fmt.Errorf("default '%s' %v", param.Default, err)
 		}
 	}
 
 	return nil
 }
+
+// parseParameterValues decodes the parameters JSON of an instantiation.
+// Numbers, booleans and arrays are accepted besides strings and converted to their string form.
+func parseParameterValues(parametersJSON string) (map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(parametersJSON), &raw); err != nil {
+		return nil, err
+	}
+
+	parameters := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch typed := value.(type) {
+		case nil:
+			continue
+		case string:
+			parameters[name] = typed
+		case float64:
+			parameters[name] = formatNumber(typed)
+		case bool:
+			parameters[name] = strconv.FormatBool(typed)
+		case []interface{}:
+			items := make([]string, len(typed))
+			for i, item := range typed {
+				items[i] = fmt.Sprint(item)
+			}
+			parameters[name] = strings.Join(items, ", ")
+		default:
+			return nil, fmt.Errorf("parameter '%s' must be a string, number, boolean or list", name)
+		}
+	}
+
+	return parameters, nil
+}
+
+// sortedKeys returns the keys of a string map in a stable order
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedParameterNames returns the parameter names in a stable order for error messages
+func sortedParameterNames(parameters map[string]contracts.Parameter) []string {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// containsValue reports whether values contains value
+func containsValue(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNumber formats a number without trailing zeros
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
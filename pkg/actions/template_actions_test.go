@@ -3,11 +3,15 @@ package actions
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mstrehse/mcp-brain/pkg/contracts"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/knowledge"
 	"github.com/mstrehse/mcp-brain/pkg/repositories/task"
 	"github.com/mstrehse/mcp-brain/pkg/repositories/template"
 )
@@ -32,11 +36,11 @@ func createTestTemplate() *contracts.TaskTemplate {
 				Values:      []string{"low", "medium", "high"},
 			},
 		},
-		Tasks: []string{
+		Tasks: contracts.TemplateTasksFromContents([]string{
 			"Setup project ${project_name}",
 			"Set priority to ${priority}",
 			"Initialize repository",
-		},
+		}),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -146,7 +150,7 @@ func TestTaskTemplateCreateHandler(t *testing.T) {
 			ID:          "invalid-template",
 			Name:        "", // Missing required name
 			Description: "Test",
-			Tasks:       []string{"task 1"},
+			Tasks:       contracts.TemplateTasksFromContents([]string{"task 1"}),
 		}
 		templateJSON, _ := json.Marshal(invalidTemplate)
 
@@ -600,7 +604,7 @@ func TestTaskTemplateInstantiateHandler(t *testing.T) {
 	}
 	defer func() { _ = taskRepo.Close() }()
 
-	handler := NewTaskTemplateInstantiateHandler(templateRepo, taskRepo)
+	handler := NewTaskTemplateInstantiateHandler(templateRepo, taskRepo, NewQueueSelector(), NewPrerequisiteChecker(nil, templateRepo, baseDir))
 
 	// Setup test data
 	testTemplate := createTestTemplate()
@@ -708,3 +712,596 @@ func TestTaskTemplateInstantiateHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestTemplateParameterTypes(t *testing.T) {
+	number := func(value float64) *float64 { return &value }
+	length := func(value int) *int { return &value }
+
+	template := &contracts.TaskTemplate{
+		Name:        "Typed Template",
+		Description: "A template with typed parameters",
+		Parameters: map[string]contracts.Parameter{
+			"count":     {Type: contracts.ParameterTypeNumber, Min: number(1), Max: number(10), Default: "3"},
+			"dry_run":   {Type: contracts.ParameterTypeBoolean},
+			"branch":    {Type: contracts.ParameterTypeString, Pattern: "[a-z0-9/-]+", MaxLength: length(20)},
+			"reviewers": {Type: contracts.ParameterTypeList, MinLength: length(1), MaxLength: length(2), Values: []string{"alice", "bob", "carol"}},
+		},
+		Tasks: contracts.TemplateTasksFromContents([]string{"Run ${count} times on ${branch}"}),
+	}
+
+	t.Run("valid definition", func(t *testing.T) {
+		if err := validateTemplate(template); err != nil {
+			t.Errorf("Expected valid template, got: %v", err)
+		}
+	})
+
+	t.Run("invalid definitions are reported together", func(t *testing.T) {
+		invalid := *template
+		invalid.Parameters = map[string]contracts.Parameter{
+			"count":  {Type: contracts.ParameterTypeNumber, Min: number(1), Max: number(10), Default: "42"},
+			"flag":   {Type: contracts.ParameterTypeBoolean, Default: "maybe"},
+			"name":   {Type: contracts.ParameterTypeString, Pattern: "("},
+			"size":   {Type: "integer"},
+			"limits": {Type: contracts.ParameterTypeString, Min: number(1)},
+		}
+
+		err := validateTemplate(&invalid)
+		if err == nil {
+			t.Fatal("Expected validation error")
+		}
+		for _, name := range []string{"count", "flag", "name", "size", "limits"} {
+			if !strings.Contains(err.Error(), "'"+name+"'") {
+				t.Errorf("Expected error to mention '%s', got: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("valid values", func(t *testing.T) {
+		err := template.ValidateParameterValues(map[string]string{
+			"count":     "10",
+			"dry_run":   "true",
+			"branch":    "feature/login",
+			"reviewers": "alice, bob",
+		})
+		if err != nil {
+			t.Errorf("Expected valid parameters, got: %v", err)
+		}
+	})
+
+	t.Run("invalid values are reported together", func(t *testing.T) {
+		tests := map[string]string{
+			"count":     "abc",
+			"dry_run":   "sometimes",
+			"branch":    "Feature Branch",
+			"reviewers": "alice, dave",
+		}
+
+		err := template.ValidateParameterValues(tests)
+		if err == nil {
+			t.Fatal("Expected validation error")
+		}
+		for name := range tests {
+			if !strings.Contains(err.Error(), "'"+name+"'") {
+				t.Errorf("Expected error to mention '%s', got: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		tests := map[string]map[string]string{
+			"number below min":  {"count": "0"},
+			"number above max":  {"count": "11"},
+			"string too long":   {"branch": "a-very-long-branch-name-indeed"},
+			"too many items":    {"reviewers": "alice, bob, carol"},
+			"pattern not whole": {"branch": "ok but not ok"},
+		}
+		for name, parameters := range tests {
+			if err := template.ValidateParameterValues(parameters); err == nil {
+				t.Errorf("%s: expected validation error", name)
+			}
+		}
+	})
+
+	t.Run("instantiate accepts JSON values", func(t *testing.T) {
+		parameters, err := parseParameterValues(`{"count": 4, "dry_run": false, "reviewers": ["alice", "carol"], "branch": "main"}`)
+		if err != nil {
+			t.Fatalf("Failed to parse parameters: %v", err)
+		}
+		if parameters["count"] != "4" || parameters["dry_run"] != "false" || parameters["reviewers"] != "alice, carol" {
+			t.Errorf("Unexpected parameter values: %v", parameters)
+		}
+		if err := template.ValidateParameterValues(parameters); err != nil {
+			t.Errorf("Expected valid parameters, got: %v", err)
+		}
+
+		if _, err := parseParameterValues(`{"count": {"value": 4}}`); err == nil {
+			t.Error("Expected error for object parameter value")
+		}
+	})
+}
+
+func TestTemplatePlaceholderChecks(t *testing.T) {
+	repo, err := template.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	handler := NewTaskTemplateCreateHandler(repo)
+
+	create := func(t *testing.T, tmpl *contracts.TaskTemplate) (*mcp.CallToolResult, string) {
+		templateJSON, _ := json.Marshal(tmpl)
+		result, err := handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "task-template-create",
+				Arguments: map[string]interface{}{"template": string(templateJSON)},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		return result, textContent.Text
+	}
+
+	t.Run("undeclared placeholders are rejected", func(t *testing.T) {
+		result, text := create(t, &contracts.TaskTemplate{
+			Name:        "Broken",
+			Description: "Uses undeclared placeholders",
+			Parameters:  map[string]contracts.Parameter{"service": {Type: "string"}},
+			Tasks:       contracts.TemplateTasksFromContents([]string{"Deploy ${service} to ${env}", "Tag ${version}"}),
+		})
+		if !result.IsError {
+			t.Fatal("Expected error result for undeclared placeholders")
+		}
+		if !strings.Contains(text, "'env'") || !strings.Contains(text, "'version'") {
+			t.Errorf("Expected both placeholders to be reported, got: %s", text)
+		}
+	})
+
+	t.Run("unused parameters are warned about", func(t *testing.T) {
+		result, text := create(t, &contracts.TaskTemplate{
+			Name:        "Unused",
+			Description: "Declares a parameter it never uses",
+			Parameters: map[string]contracts.Parameter{
+				"service": {Type: "string"},
+				"owner":   {Type: "string"},
+			},
+			Tasks: contracts.TemplateTasksFromContents([]string{"Deploy ${service}"}),
+		})
+		if result.IsError {
+			t.Fatalf("Expected success, got: %s", text)
+		}
+
+		var createResult map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &createResult); err != nil {
+			t.Fatalf("Result is not valid JSON: %v", err)
+		}
+		warnings, _ := createResult["warnings"].([]interface{})
+		if len(warnings) != 1 || !strings.Contains(warnings[0].(string), "'owner'") {
+			t.Errorf("Expected a warning about 'owner', got: %v", createResult["warnings"])
+		}
+	})
+}
+
+func TestTemplateStructuredTasks(t *testing.T) {
+	baseDir := t.TempDir()
+	templateRepo, err := template.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create template repository: %v", err)
+	}
+	defer func() { _ = templateRepo.Close() }()
+
+	taskRepo, err := task.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create task repository: %v", err)
+	}
+	defer func() { _ = taskRepo.Close() }()
+
+	createHandler := NewTaskTemplateCreateHandler(templateRepo)
+	instantiateHandler := NewTaskTemplateInstantiateHandler(templateRepo, taskRepo, NewQueueSelector(), NewPrerequisiteChecker(nil, templateRepo, baseDir))
+
+	call := func(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) (*mcp.CallToolResult, string) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		return result, textContent.Text
+	}
+
+	t.Run("mixed string and structured tasks", func(t *testing.T) {
+		templateJSON := `{
+			"id": "review",
+			"name": "Review",
+			"description": "Reviews a change",
+			"parameters": {
+				"has_db": {"type": "boolean", "default": "false"},
+				"services": {"type": "list", "required": true}
+			},
+			"tasks": [
+				"Read the diff",
+				{"content": "Run DB migration check", "when": "has_db"},
+				{"content": "Review ${item}", "for_each": "services"}
+			]
+		}`
+		if result, text := call(t, createHandler, map[string]interface{}{"template": templateJSON}); result.IsError {
+			t.Fatalf("Expected success, got: %s", text)
+		}
+
+		result, text := call(t, instantiateHandler, map[string]interface{}{
+			"template_id": "review",
+			"parameters":  `{"has_db": true, "services": ["api", "worker"]}`,
+		})
+		if result.IsError {
+			t.Fatalf("Expected success, got: %s", text)
+		}
+		var instantiateResult map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &instantiateResult); err != nil {
+			t.Fatalf("Result is not valid JSON: %v", err)
+		}
+		if tasksAdded, ok := instantiateResult["tasks_added"].(float64); !ok || int(tasksAdded) != 4 {
+			t.Errorf("Expected 4 tasks, got: %v", instantiateResult["tasks_added"])
+		}
+	})
+
+	t.Run("invalid structured tasks are rejected", func(t *testing.T) {
+		templateJSON := `{
+			"name": "Broken",
+			"description": "Uses conditions wrongly",
+			"parameters": {"name": {"type": "string"}},
+			"tasks": [
+				{"content": "Greet ${item}", "for_each": "name"},
+				{"content": "Check", "when": "name >= 3"},
+				{"content": "Deploy", "when": "has_db"}
+			]
+		}`
+		result, text := call(t, createHandler, map[string]interface{}{"template": templateJSON})
+		if !result.IsError {
+			t.Fatal("Expected error result")
+		}
+		for _, expected := range []string{"task 1", "task 2", "'has_db'"} {
+			if !strings.Contains(text, expected) {
+				t.Errorf("Expected error to mention %s, got: %s", expected, text)
+			}
+		}
+	})
+}
+
+func TestTemplateIncludes(t *testing.T) {
+	repo, err := template.NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	createHandler := NewTaskTemplateCreateHandler(repo)
+	updateHandler := NewTaskTemplateUpdateHandler(repo)
+	deleteHandler := NewTaskTemplateDeleteHandler(repo)
+
+	call := func(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) (*mcp.CallToolResult, string) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		return result, textContent.Text
+	}
+
+	tail := `{"id": "tail", "name": "Tail", "description": "Common steps", "parameters": {"component": {"type": "string", "required": true}}, "tasks": ["Lint ${component}"]}`
+	if result, text := call(t, createHandler, map[string]interface{}{"template": tail}); result.IsError {
+		t.Fatalf("Failed to create tail template: %s", text)
+	}
+
+	t.Run("include with mapping", func(t *testing.T) {
+		feature := `{"id": "feature", "name": "Feature", "description": "Builds a feature", "parameters": {"name": {"type": "string"}}, "tasks": ["Implement ${name}", {"include": "tail", "with": {"component": "${name}"}}]}`
+		if result, text := call(t, createHandler, map[string]interface{}{"template": feature}); result.IsError {
+			t.Fatalf("Expected success, got: %s", text)
+		}
+	})
+
+	t.Run("invalid includes are rejected", func(t *testing.T) {
+		broken := `{"name": "Broken", "description": "Includes wrongly", "tasks": [{"include": "missing"}, {"include": "tail", "with": {"unknown": "x"}}]}`
+		result, text := call(t, createHandler, map[string]interface{}{"template": broken})
+		if !result.IsError {
+			t.Fatal("Expected error result")
+		}
+		for _, expected := range []string{"'missing' not found", "no parameter 'unknown'", "requires parameter 'component'"} {
+			if !strings.Contains(text, expected) {
+				t.Errorf("Expected error to mention %q, got: %s", expected, text)
+			}
+		}
+	})
+
+	t.Run("cycles are rejected on update", func(t *testing.T) {
+		cyclic := `{"id": "tail", "name": "Tail", "description": "Common steps", "parameters": {"component": {"type": "string", "required": true}}, "tasks": ["Lint ${component}", {"include": "feature", "with": {"name": "${component}"}}]}`
+		result, text := call(t, updateHandler, map[string]interface{}{"template": cyclic})
+		if !result.IsError || !strings.Contains(text, "tail -> feature -> tail") {
+			t.Errorf("Expected include cycle error, got: %s", text)
+		}
+	})
+
+	t.Run("delete refuses included templates", func(t *testing.T) {
+		result, text := call(t, deleteHandler, map[string]interface{}{"template_id": "tail"})
+		if !result.IsError || !strings.Contains(text, "feature") {
+			t.Fatalf("Expected refusal naming 'feature', got: %s", text)
+		}
+
+		result, text = call(t, deleteHandler, map[string]interface{}{"template_id": "tail", "force": true})
+		if result.IsError || !strings.Contains(text, "warnings") {
+			t.Errorf("Expected forced delete with warning, got: %s", text)
+		}
+	})
+}
+
+func TestTemplatePrerequisites(t *testing.T) {
+	baseDir := t.TempDir()
+	workspaceDir := t.TempDir()
+	knowledgeRepo, err := knowledge.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create knowledge repository: %v", err)
+	}
+	templateRepo, err := template.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create template repository: %v", err)
+	}
+	defer func() { _ = templateRepo.Close() }()
+	taskRepo, err := task.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create task repository: %v", err)
+	}
+	defer func() { _ = taskRepo.Close() }()
+
+	createHandler := NewTaskTemplateCreateHandler(templateRepo)
+	instantiateHandler := NewTaskTemplateInstantiateHandler(templateRepo, taskRepo, NewQueueSelector(), NewPrerequisiteChecker(knowledgeRepo, templateRepo, workspaceDir))
+
+	call := func(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) (*mcp.CallToolResult, string) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		return result, textContent.Text
+	}
+
+	setup := `{"id": "setup", "name": "Setup", "description": "Sets up the project", "tasks": ["Install tools"]}`
+	if result, text := call(t, createHandler, map[string]interface{}{"template": setup}); result.IsError {
+		t.Fatalf("Failed to create setup template: %s", text)
+	}
+	release := `{"id": "release", "name": "Release", "description": "Releases the project",
+		"parameters": {"project": {"type": "string", "default": "app"}},
+		"prerequisites": [
+			"Talk to the release manager",
+			{"type": "memory", "project": "${project}", "path": "release.md"},
+			{"type": "file", "path": "go.mod"},
+			{"type": "command", "command": "go"},
+			{"type": "command", "command": "surely-not-installed-anywhere"},
+			{"type": "template", "template": "setup"}
+		],
+		"tasks": ["Tag the release"]}`
+	if result, text := call(t, createHandler, map[string]interface{}{"template": release}); result.IsError {
+		t.Fatalf("Failed to create release template: %s", text)
+	}
+
+	t.Run("invalid prerequisites are rejected", func(t *testing.T) {
+		broken := `{"name": "Broken", "description": "Broken prerequisites", "prerequisites": [{"type": "memory"}, {"type": "weather"}], "tasks": ["Do it"]}`
+		result, text := call(t, createHandler, map[string]interface{}{"template": broken})
+		if !result.IsError {
+			t.Fatal("Expected error result")
+		}
+		for _, expected := range []string{"prerequisite 1: memory prerequisites need a project and a path", "prerequisite 2: unknown prerequisite type 'weather'"} {
+			if !strings.Contains(text, expected) {
+				t.Errorf("Expected error to mention %q, got: %s", expected, text)
+			}
+		}
+	})
+
+	t.Run("unmet prerequisites are all reported", func(t *testing.T) {
+		result, text := call(t, instantiateHandler, map[string]interface{}{"template_id": "release"})
+		if !result.IsError {
+			t.Fatalf("Expected error result, got: %s", text)
+		}
+		for _, expected := range []string{"release.md", "go.mod", "surely-not-installed-anywhere", "setup"} {
+			if !strings.Contains(text, expected) {
+				t.Errorf("Expected error to mention %q, got: %s", expected, text)
+			}
+		}
+		if strings.Contains(text, "command 'go'") {
+			t.Errorf("Expected 'go' to be found on the PATH, got: %s", text)
+		}
+
+		tasks, err := taskRepo.GetAllTasks(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get tasks: %v", err)
+		}
+		if len(tasks) != 0 {
+			t.Errorf("Expected no tasks to be added, got %d", len(tasks))
+		}
+	})
+
+	t.Run("force instantiates anyway", func(t *testing.T) {
+		result, text := call(t, instantiateHandler, map[string]interface{}{"template_id": "release", "force": true})
+		if result.IsError {
+			t.Fatalf("Expected success, got: %s", text)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &response); err != nil {
+			t.Fatalf("Failed to unmarshal result: %v", err)
+		}
+		if unmet, ok := response["unmet_prerequisites"].([]interface{}); !ok || len(unmet) != 4 {
+			t.Errorf("Expected 4 unmet prerequisites, got %v", response["unmet_prerequisites"])
+		}
+		if notes, ok := response["prerequisite_notes"].([]interface{}); !ok || len(notes) != 1 {
+			t.Errorf("Expected the note to be returned, got %v", response["prerequisite_notes"])
+		}
+	})
+
+	t.Run("met prerequisites allow instantiation", func(t *testing.T) {
+		if err := knowledgeRepo.Write("app", "release.md", "# Release"); err != nil {
+			t.Fatalf("Failed to write memory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(workspaceDir, "go.mod"), []byte("module app\n"), 0644); err != nil {
+			t.Fatalf("Failed to write workspace file: %v", err)
+		}
+		if result, text := call(t, instantiateHandler, map[string]interface{}{"template_id": "setup"}); result.IsError {
+			t.Fatalf("Failed to instantiate setup template: %s", text)
+		}
+
+		result, text := call(t, instantiateHandler, map[string]interface{}{"template_id": "release"})
+		if !result.IsError || !strings.Contains(text, "surely-not-installed-anywhere") || strings.Contains(text, "release.md") || strings.Contains(text, "setup") {
+			t.Errorf("Expected only the missing command to be reported, got: %s", text)
+		}
+	})
+
+	t.Run("prerequisites of included templates are checked", func(t *testing.T) {
+		build := `{"id": "build", "name": "Build", "description": "Builds a component",
+			"parameters": {"component": {"type": "string", "required": true}},
+			"prerequisites": [{"type": "file", "path": "${component}/Makefile"}, "Update the changelog"],
+			"tasks": ["Build ${component}"]}`
+		if result, text := call(t, createHandler, map[string]interface{}{"template": build}); result.IsError {
+			t.Fatalf("Failed to create build template: %s", text)
+		}
+		ship := `{"id": "ship", "name": "Ship", "description": "Builds and ships components",
+			"parameters": {"components": {"type": "list", "required": true}},
+			"tasks": [{"include": "build", "for_each": "components", "with": {"component": "${item}"}}, "Ship it"]}`
+		if result, text := call(t, createHandler, map[string]interface{}{"template": ship}); result.IsError {
+			t.Fatalf("Failed to create ship template: %s", text)
+		}
+
+		result, text := call(t, instantiateHandler, map[string]interface{}{"template_id": "ship", "parameters": `{"components": "api,web"}`})
+		if !result.IsError {
+			t.Fatalf("Expected error result, got: %s", text)
+		}
+		for _, expected := range []string{"api/Makefile", "web/Makefile", `"template":"build"`} {
+			if !strings.Contains(text, expected) {
+				t.Errorf("Expected error to mention %q, got: %s", expected, text)
+			}
+		}
+
+		for _, component := range []string{"api", "web"} {
+			if err := os.MkdirAll(filepath.Join(workspaceDir, component), 0755); err != nil {
+				t.Fatalf("Failed to create component directory: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(workspaceDir, component, "Makefile"), []byte("all:\n"), 0644); err != nil {
+				t.Fatalf("Failed to write workspace file: %v", err)
+			}
+		}
+		result, text = call(t, instantiateHandler, map[string]interface{}{"template_id": "ship", "parameters": `{"components": "api,web"}`})
+		if result.IsError {
+			t.Fatalf("Expected success, got: %s", text)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &response); err != nil {
+			t.Fatalf("Failed to unmarshal result: %v", err)
+		}
+		if notes, ok := response["prerequisite_notes"].([]interface{}); !ok || len(notes) != 1 {
+			t.Errorf("Expected the note of the included template once, got %v", response["prerequisite_notes"])
+		}
+	})
+}
+
+func TestTemplateHistoryAndRollback(t *testing.T) {
+	baseDir := t.TempDir()
+	templateRepo, err := template.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create template repository: %v", err)
+	}
+	defer func() { _ = templateRepo.Close() }()
+	taskRepo, err := task.NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create task repository: %v", err)
+	}
+	defer func() { _ = taskRepo.Close() }()
+
+	updateHandler := NewTaskTemplateUpdateHandler(templateRepo)
+	historyHandler := NewTaskTemplateHistoryHandler(templateRepo)
+	rollbackHandler := NewTaskTemplateRollbackHandler(templateRepo)
+	getHandler := NewTaskTemplateGetHandler(templateRepo)
+	instantiateHandler := NewTaskTemplateInstantiateHandler(templateRepo, taskRepo, NewQueueSelector(), NewPrerequisiteChecker(nil, templateRepo, baseDir))
+
+	call := func(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) (*mcp.CallToolResult, string) {
+		result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: arguments}})
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		if !ok {
+			t.Fatal("Expected text content")
+		}
+		return result, textContent.Text
+	}
+
+	if err := templateRepo.CreateTemplate(createTestTemplate()); err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+	broken := `{"id": "test-template-1", "name": "Test Template", "description": "Broken by a bad edit", "tasks": ["Do something else"]}`
+	if result, text := call(t, updateHandler, map[string]interface{}{"template": broken}); result.IsError || !strings.Contains(text, `"version":2`) {
+		t.Fatalf("Expected update to version 2, got: %s", text)
+	}
+
+	t.Run("history lists all versions", func(t *testing.T) {
+		result, text := call(t, historyHandler, map[string]interface{}{"template_id": "test-template-1"})
+		if result.IsError {
+			t.Fatalf("Expected success, got: %s", text)
+		}
+		var response struct {
+			CurrentVersion int                          `json:"current_version"`
+			Versions       []*contracts.TemplateVersion `json:"versions"`
+		}
+		if err := json.Unmarshal([]byte(text), &response); err != nil {
+			t.Fatalf("Failed to unmarshal result: %v", err)
+		}
+		if response.CurrentVersion != 2 || len(response.Versions) != 2 || response.Versions[0].Tasks != 3 {
+			t.Errorf("Expected 2 versions with the original first, got %s", text)
+		}
+	})
+
+	t.Run("get and instantiate pinned versions", func(t *testing.T) {
+		result, text := call(t, getHandler, map[string]interface{}{"template_id": "test-template-1", "version": float64(1)})
+		if result.IsError || !strings.Contains(text, "A test template for validation") {
+			t.Errorf("Expected version 1, got: %s", text)
+		}
+
+		result, text = call(t, instantiateHandler, map[string]interface{}{"template_id": "test-template-1", "version": float64(1), "parameters": `{"project_name": "Pinned"}`})
+		if result.IsError || !strings.Contains(text, "Setup project Pinned") || !strings.Contains(text, `"version":1`) {
+			t.Errorf("Expected tasks of version 1, got: %s", text)
+		}
+
+		result, text = call(t, instantiateHandler, map[string]interface{}{"template_id": "test-template-1", "version": float64(9)})
+		if !result.IsError || !strings.Contains(text, "no version 9") {
+			t.Errorf("Expected missing version error, got: %s", text)
+		}
+	})
+
+	t.Run("rollback restores a version", func(t *testing.T) {
+		result, text := call(t, rollbackHandler, map[string]interface{}{"template_id": "test-template-1"})
+		if !result.IsError {
+			t.Errorf("Expected error without version, got: %s", text)
+		}
+
+		result, text = call(t, rollbackHandler, map[string]interface{}{"template_id": "test-template-1", "version": float64(1)})
+		if result.IsError || !strings.Contains(text, `"version":3`) {
+			t.Fatalf("Expected rollback to version 3, got: %s", text)
+		}
+
+		current, err := templateRepo.GetTemplate("test-template-1")
+		if err != nil {
+			t.Fatalf("Failed to get template: %v", err)
+		}
+		if current.Description != "A test template for validation" || len(current.Tasks) != 3 {
+			t.Errorf("Expected the original template to be restored, got %+v", current)
+		}
+	})
+}
@@ -1,5 +1,213 @@
 package contracts
 
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Question is a question for the user: free text, a choice from a list or a yes/no confirmation
+type Question struct {
+	Text string `json:"question"`
+
+	// Choices lets the user pick from a list instead of typing an answer
+	Choices []string `json:"choices,omitempty"`
+
+	// MultiSelect allows picking several choices
+	MultiSelect bool `json:"multi_select,omitempty"`
+
+	// Default is the prefilled answer: text, a choice (comma-separated choices with MultiSelect), or yes/no with Confirm
+	Default string `json:"default,omitempty"`
+
+	// Confirm asks a yes/no question
+	Confirm bool `json:"confirm,omitempty"`
+}
+
+// Answer is the answer of the user to a question
+type Answer struct {
+	// Text is the typed answer, or the picked choice of a single choice question
+	Text string `json:"answer,omitempty"`
+
+	// Selected holds the picked choices of a choice question
+	Selected []string `json:"selected,omitempty"`
+
+	// Confirmed is the answer to a yes/no question
+	Confirmed *bool `json:"confirmed,omitempty"`
+
+	// TimedOut is set when the user didn't answer in time and the default was taken instead
+	TimedOut bool `json:"timed_out,omitempty"`
+
+	// Reused is set when the answer was taken from an earlier decision instead of asking
+	Reused bool `json:"reused,omitempty"`
+}
+
+// Validate checks that the options of the question fit together
+func (q Question) Validate() error {
+	if strings.TrimSpace(q.Text) == "" {
+		return fmt.Errorf("question must not be empty")
+	}
+	if q.Confirm && len(q.Choices) > 0 {
+		return fmt.Errorf("confirm questions can't have choices")
+	}
+	if q.MultiSelect && len(q.Choices) == 0 {
+		return fmt.Errorf("multi_select needs choices")
+	}
+	for _, choice := range q.Choices {
+		if strings.TrimSpace(choice) == "" || strings.ContainsAny(choice, "\r\n") {
+			return fmt.Errorf("choices must be non-empty single lines")
+		}
+	}
+	if q.Confirm && q.Default != "" {
+		if _, err := ParseConfirmation(q.Default); err != nil {
+			return fmt.Errorf("default of confirm questions must be yes or no")
+		}
+	}
+	for _, item := range q.DefaultChoices() {
+		if !containsString(q.Choices, item) {
+			return fmt.Errorf("default '%s' is not one of the choices", item)
+		}
+	}
+	return nil
+}
+
+// DefaultChoices returns the choices that are preselected by the default
+func (q Question) DefaultChoices() []string {
+	if len(q.Choices) == 0 || q.Default == "" {
+		return nil
+	}
+	if !q.MultiSelect {
+		return []string{q.Default}
+	}
+	return SplitListValue(q.Default)
+}
+
+// DefaultAnswer returns the answer taken when the user doesn't answer in time, or nil without a default
+func (q Question) DefaultAnswer() *Answer {
+	if q.Default == "" {
+		return nil
+	}
+	answer, err := ParseTextAnswer(q, q.Default)
+	if err != nil {
+		return nil
+	}
+	answer.TimedOut = true
+	return answer
+}
+
+// ParseConfirmation reads a yes/no answer
+func ParseConfirmation(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "y", "true":
+		return true, nil
+	case "no", "n", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("'%s' is neither yes nor no", value)
+}
+
+// NewChoiceAnswer creates the answer to a choice question, checking that only offered choices were picked
+func NewChoiceAnswer(question Question, selected []string) (*Answer, error) {
+	for _, choice := range selected {
+		if !containsString(question.Choices, choice) {
+			return nil, fmt.Errorf("'%s' is not one of the choices", choice)
+		}
+	}
+	answer := &Answer{Selected: selected}
+	if !question.MultiSelect && len(selected) == 1 {
+		answer.Text = selected[0]
+	}
+	return answer, nil
+}
+
+// NewConfirmAnswer creates the answer to a yes/no question
+func NewConfirmAnswer(confirmed bool) *Answer {
+	return &Answer{Confirmed: &confirmed}
+}
+
+// FormAnswer holds the fields of an answer submitted through a form, as clients and web pages send them.
+// The field names match the JSON fields of Answer.
+type FormAnswer struct {
+	Answer    *string  `json:"answer"`
+	Selected  []string `json:"selected"`
+	Confirmed *bool    `json:"confirmed"`
+}
+
+// ParseFormAnswer creates the answer to a question from a submitted form, checking that it fits the question
+func ParseFormAnswer(question Question, form FormAnswer) (*Answer, error) {
+	switch {
+	case question.Confirm:
+		if form.Confirmed == nil {
+			return nil, fmt.Errorf("the form contains no confirmation")
+		}
+		return NewConfirmAnswer(*form.Confirmed), nil
+	case question.MultiSelect:
+		if form.Selected == nil {
+			form.Selected = []string{}
+		}
+		return NewChoiceAnswer(question, form.Selected)
+	}
+
+	if form.Answer == nil {
+		return nil, fmt.Errorf("the form contains no answer")
+	}
+	if len(question.Choices) > 0 {
+		return NewChoiceAnswer(question, []string{*form.Answer})
+	}
+	return &Answer{Text: *form.Answer}, nil
+}
+
+// ParseTextAnswer reads an answer typed as text, as headless backends receive them.
+// Choices are picked by their text or number (comma-separated with MultiSelect),
+// and an empty answer takes the default.
+func ParseTextAnswer(question Question, text string) (*Answer, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		text = question.Default
+	}
+
+	if question.Confirm {
+		confirmed, err := ParseConfirmation(text)
+		if err != nil {
+			return nil, err
+		}
+		return NewConfirmAnswer(confirmed), nil
+	}
+
+	if len(question.Choices) == 0 {
+		return &Answer{Text: text}, nil
+	}
+
+	items := []string{text}
+	if question.MultiSelect {
+		items = SplitListValue(text)
+	}
+	selected := []string{}
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		if number, err := strconv.Atoi(item); err == nil && number >= 1 && number <= len(question.Choices) && !containsString(question.Choices, item) {
+			item = question.Choices[number-1]
+		}
+		selected = append(selected, item)
+	}
+	return NewChoiceAnswer(question, selected)
+}
+
+// containsString reports whether a value is in a list
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// AskRepository shows questions to the user and waits for the answer.
+// Ask gives up with the error of ctx once it is done, closing the dialog,
+// and returns ErrQuestionDismissed when the user closed it without answering.
 type AskRepository interface {
-	Ask(question string) (string, error)
+	Ask(ctx context.Context, question Question) (*Answer, error)
 }
@@ -0,0 +1,39 @@
+package contracts
+
+import "time"
+
+// Decision is a question the user answered, kept so later sessions don't ask again
+type Decision struct {
+	ID string `json:"id"`
+	Question
+
+	Answer  *Answer   `json:"answer"`
+	Session string    `json:"session,omitempty"`
+	AskedAt time.Time `json:"asked_at"`
+}
+
+// DecisionQuery describes a search over the recorded decisions
+type DecisionQuery struct {
+	Query   string `json:"query"`
+	Session string `json:"session,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+// DecisionResult is a decision matching a search query
+type DecisionResult struct {
+	*Decision
+	Score float64 `json:"score"`
+}
+
+// DecisionRepository records the answers of the user
+type DecisionRepository interface {
+	// Record appends a decision to the log, assigning its ID
+	Record(decision *Decision) (*Decision, error)
+
+	// Search returns the decisions whose question, choices or answer match the query, best matches first
+	Search(query DecisionQuery) ([]*DecisionResult, error)
+
+	// Find returns the latest decision the user made for the same question, or nil if there is none.
+	// Questions match when their text, choices and kind are the same; answers taken after a timeout don't count.
+	Find(question Question) (*Decision, error)
+}
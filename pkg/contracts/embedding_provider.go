@@ -0,0 +1,10 @@
+package contracts
+
+// EmbeddingProvider turns text into vectors whose cosine similarity reflects how related the texts are
+type EmbeddingProvider interface {
+	// Name identifies the provider and its model, so stored vectors can be invalidated when it changes
+	Name() string
+
+	// Embed returns one vector per text
+	Embed(texts []string) ([][]float32, error)
+}
@@ -0,0 +1,56 @@
+package contracts
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEmptyPath is returned when a knowledge path is empty
+	ErrEmptyPath = errors.New("path must not be empty")
+
+	// ErrAbsolutePath is returned when a knowledge path is absolute
+	ErrAbsolutePath = errors.New("absolute paths are not allowed")
+
+	// ErrPathTraversal is returned when a knowledge path contains '..' segments
+	ErrPathTraversal = errors.New("'..' segments are not allowed")
+
+	// ErrReservedName is returned when a knowledge path uses a hidden or reserved name
+	ErrReservedName = errors.New("hidden or reserved names are not allowed")
+
+	// ErrInvalidProject is returned when a project name is not a single folder name
+	ErrInvalidProject = errors.New("project must be a single folder name")
+
+	// ErrSymlinkEscape is returned when a knowledge path leads outside the knowledge directory via a symlink
+	ErrSymlinkEscape = errors.New("path leads outside of the knowledge directory")
+
+	// ErrQuestionDismissed is returned when the user closed a question without answering it
+	ErrQuestionDismissed = errors.New("the user dismissed the question without answering")
+
+	// ErrQuestionDeclined is returned when the user explicitly refused to answer a question
+	ErrQuestionDeclined = errors.New("the user declined to answer the question")
+)
+
+// InvalidPathError reports a path that was rejected before touching the filesystem
+type InvalidPathError struct {
+	Path string
+	Err  error
+}
+
+func (e *InvalidPathError) Error() string {
+	return fmt.Sprintf("invalid path %q: %v", e.Path, e.Err)
+}
+
+func (e *InvalidPathError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidTemplateIDError reports a template ID that can't be used as a file name,
+// rejected before touching the filesystem
+type InvalidTemplateIDError struct {
+	ID string
+}
+
+func (e *InvalidTemplateIDError) Error() string {
+	return fmt.Sprintf("invalid template ID %q: use letters, digits, '-' and '_' only, starting with a letter or digit", e.ID)
+}
@@ -1,19 +1,64 @@
 package contracts
 
+// DefaultProject is the project that knowledge stored before projects existed is migrated into
+const DefaultProject = "default"
+
 // DirStructure represents the hierarchical structure of directories and files
 type DirStructure map[string]DirStructure
 
+// SearchQuery describes a full-text search over the knowledge of a project
+type SearchQuery struct {
+	Query      string `json:"query"`
+	PathPrefix string `json:"path_prefix,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+// SearchResult is a knowledge file matching a search query
+type SearchResult struct {
+	Path     string    `json:"path"`
+	Score    float64   `json:"score"`
+	Snippets []Snippet `json:"snippets"`
+}
+
+// Snippet is a line of a knowledge file that matches a search query
+type Snippet struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// RelatedQuery describes a similarity search for knowledge related to a text or an existing knowledge file
+type RelatedQuery struct {
+	Text  string `json:"text,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// RelatedResult is a knowledge file similar to a related query
+type RelatedResult struct {
+	Path       string  `json:"path"`
+	Similarity float64 `json:"similarity"`
+}
+
 // KnowledgeRepository defines the interface for knowledge storage operations
 type KnowledgeRepository interface {
-	// List returns a json representation of the directory and file structure
-	List() (DirStructure, error)
+	// ListProjects returns the names of all projects that contain knowledge
+	ListProjects() ([]string, error)
+
+	// List returns a json representation of the directory and file structure of a project
+	List(project string) (DirStructure, error)
+
+	// Write knowledge of a project to the filesystem
+	Write(project string, path string, content string) error
+
+	// Read knowledge of a project from the filesystem
+	Read(project string, path string) (string, error)
 
-	// Write knowledge to the filesystem
-	Write(path string, content string) error
+	// Delete knowledge of a project from the filesystem
+	Delete(project string, path string) error
 
-	// Read knowledge from the filesystem
-	Read(path string) (string, error)
+	// Search returns the knowledge files of a project ranked by relevance to the query
+	Search(project string, query SearchQuery) ([]*SearchResult, error)
 
-	// Delete knowledge from the filesystem
-	Delete(path string) error
+	// Related returns the knowledge files of a project that are most similar to the query
+	Related(project string, query RelatedQuery) ([]*RelatedResult, error)
 }
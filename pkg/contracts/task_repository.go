@@ -1,20 +1,147 @@
 package contracts
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultQueue is the task queue used by clients without a session of their own
+const DefaultQueue = "default"
+
+// queueNamePattern restricts queue names to characters that are safe in file names
+var queueNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// ValidateQueueName checks that a queue name can be used to store the queue
+func ValidateQueueName(name string) error {
+	if !queueNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid queue name '%s': use letters, digits, '.', '_' and '-' only", name)
+	}
+	return nil
+}
+
+// TaskStatus describes where a task is in its lifecycle
+type TaskStatus string
+
+const (
+	// TaskStatusPending marks a task that waits to be picked up
+	TaskStatusPending TaskStatus = "pending"
+
+	// TaskStatusInProgress marks a task that was handed out and is being worked on
+	TaskStatusInProgress TaskStatus = "in_progress"
+
+	// TaskStatusDone marks a task that was completed successfully
+	TaskStatusDone TaskStatus = "done"
+
+	// TaskStatusFailed marks a task that could not be completed
+	TaskStatusFailed TaskStatus = "failed"
+
+	// TaskStatusCancelled marks a task that is no longer needed
+	TaskStatusCancelled TaskStatus = "cancelled"
+)
+
+// IsFinished reports whether the status is final
+func (s TaskStatus) IsFinished() bool {
+	return s == TaskStatusDone || s == TaskStatusFailed || s == TaskStatusCancelled
+}
 
 // Task represents a task in the queue
 type Task struct {
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          string     `json:"id"`
+	Content     string     `json:"content"`
+	Status      TaskStatus `json:"status"`
+	Priority    int        `json:"priority,omitempty"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	DependsOn   []string   `json:"depends_on,omitempty"`
+	BlockedBy   []string   `json:"blocked_by,omitempty" yaml:"-"` // unfinished dependencies, computed on read
+	Outcome     string     `json:"outcome,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// NewTask describes a task to be added to the queue
+type NewTask struct {
+	Content string `json:"content"`
+
+	// Priority lets a task jump ahead of tasks with a lower priority (defaults to 0)
+	Priority int `json:"priority,omitempty"`
+
+	// DependsOn lists the IDs of tasks that have to be done first.
+	// "#n" refers to the n-th task added in the same call.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// TaskPlacement describes where tasks are put in a queue. The zero value appends them.
+type TaskPlacement struct {
+	// Front puts the tasks before all other tasks
+	Front bool
+
+	// After puts the tasks right after the task with this ID and its subtasks
+	After string
+
+	// Position puts the tasks at this 1-based position of the queue
+	Position int
+}
+
+// NewTasksFromContents creates independent new tasks from plain descriptions
+func NewTasksFromContents(contents []string) []NewTask {
+	tasks := make([]NewTask, len(contents))
+	for i, content := range contents {
+		tasks[i] = NewTask{Content: content}
+	}
+	return tasks
+}
+
+// TaskQueue summarizes a named task queue
+type TaskQueue struct {
+	Name       string    `json:"name"`
+	Total      int       `json:"total"`
+	Pending    int       `json:"pending"`
+	InProgress int       `json:"in_progress"`
+	LastUpdate time.Time `json:"last_update"`
 }
 
 // TaskRepository defines the interface for task queue operations
 type TaskRepository interface {
-	// AddTasks adds multiple tasks to the queue
-	AddTasks(contents []string) ([]*Task, error)
+	// ListQueues returns all active task queues
+	ListQueues() ([]*TaskQueue, error)
+
+	// ArchiveQueue moves a queue out of the way, so its name starts over with an empty queue
+	ArchiveQueue(queue string) error
+
+	// AddTasks adds multiple tasks to a queue, rejecting unknown or cyclic dependencies
+	AddTasks(queue string, tasks []NewTask) ([]*Task, error)
+
+	// InsertTasks adds multiple tasks to a queue at the given placement instead of appending them
+	InsertTasks(queue string, tasks []NewTask, placement TaskPlacement) ([]*Task, error)
+
+	// MoveTask moves a task together with its subtasks to another place in the queue
+	MoveTask(queue string, id string, placement TaskPlacement) (*Task, error)
+
+	// AddSubtasks splits a task by adding subtasks that run before the tasks queued after it.
+	// The parent is marked done automatically once all of its subtasks are done.
+	AddSubtasks(queue string, parentID string, tasks []NewTask) ([]*Task, error)
+
+	// GetTask retrieves the pending task with the highest priority whose dependencies are done
+	// and marks it in progress. Tasks with the same priority are handed out in queue order.
+	GetTask(queue string) (*Task, error)
+
+	// PeekTask returns the task GetTask would hand out next without changing it
+	PeekTask(queue string) (*Task, error)
+
+	// GetAllTasks returns all tasks in a queue, including finished ones
+	GetAllTasks(queue string) ([]*Task, error)
+
+	// GetTaskCount returns the number of pending tasks in a queue
+	GetTaskCount(queue string) (int, error)
+
+	// FinishTask moves a task into a final status and records an outcome note.
+	// Unfinished subtasks of the task are cancelled.
+	FinishTask(queue string, id string, status TaskStatus, outcome string) (*Task, error)
 
-	// GetTask retrieves and removes the next pending task from the queue
-	GetTask() (*Task, error)
+	// ReleaseTask puts an in-progress task back to pending, e.g. after the agent working on it crashed
+	ReleaseTask(queue string, id string) (*Task, error)
 
 	// Close closes the repository and cleans up resources
 	Close() error
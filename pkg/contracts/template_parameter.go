@@ -0,0 +1,121 @@
+package contracts
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateParameterValues checks resolved parameter values against the parameters of the template,
+// reporting every offending parameter at once
+func (t *TaskTemplate) ValidateParameterValues(parameters map[string]string) error {
+	names := make([]string, 0, len(t.Parameters))
+	for name := range t.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, paramName := range names {
+		param := t.Parameters[paramName]
+
+		value, exists := parameters[paramName]
+		if !exists || value == "" {
+			if param.Required {
+				problems = append(problems, fmt.Sprintf("required parameter '%s' is missing", paramName))
+			}
+			continue
+		}
+
+		if err := param.ValidateValue(value); err != nil {
+			problems = append(problems, fmt.Sprintf("parameter '%s' %v", paramName, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// ValidateValue checks a single value against the type and constraints of the parameter
+func (p Parameter) ValidateValue(value string) error {
+	switch p.Type {
+	case ParameterTypeEnum:
+		if !slices.Contains(p.Values, value) {
+			return fmt.Errorf("must be one of: %s", strings.Join(p.Values, ", "))
+		}
+
+	case ParameterTypeNumber:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return fmt.Errorf("must be a number")
+		}
+		if p.Min != nil && number < *p.Min {
+			return fmt.Errorf("must be at least %s", strconv.FormatFloat(*p.Min, 'f', -1, 64))
+		}
+		if p.Max != nil && number > *p.Max {
+			return fmt.Errorf("must be at most %s", strconv.FormatFloat(*p.Max, 'f', -1, 64))
+		}
+
+	case ParameterTypeBoolean:
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+
+	case ParameterTypeList:
+		items := SplitListValue(value)
+		if err := p.checkLength(len(items), "items"); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if len(p.Values) > 0 && !slices.Contains(p.Values, item) {
+				return fmt.Errorf("item '%s' must be one of: %s", item, strings.Join(p.Values, ", "))
+			}
+			if err := p.checkPattern(item); err != nil {
+				return fmt.Errorf("item '%s' %v", item, err)
+			}
+		}
+
+	default:
+		if err := p.checkLength(utf8.RuneCountInString(value), "characters"); err != nil {
+			return err
+		}
+		if err := p.checkPattern(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkLength checks a length against the min_length and max_length of the parameter
+func (p Parameter) checkLength(length int, unit string) error {
+	if p.MinLength != nil && length < *p.MinLength {
+		return fmt.Errorf("must have at least %d %s", *p.MinLength, unit)
+	}
+	if p.MaxLength != nil && length > *p.MaxLength {
+		return fmt.Errorf("must have at most %d %s", *p.MaxLength, unit)
+	}
+	return nil
+}
+
+// checkPattern checks that a value matches the whole pattern of the parameter
+func (p Parameter) checkPattern(value string) error {
+	if p.Pattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile("^(?:" + p.Pattern + ")$")
+	if err != nil {
+		return fmt.Errorf("cannot be checked, invalid pattern: %v", err)
+	}
+	if !pattern.MatchString(value) {
+		return fmt.Errorf("must match pattern '%s'", p.Pattern)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Prerequisite types checked before a template is instantiated
+const (
+	// PrerequisiteTypeMemory requires a memory to exist in the brain
+	PrerequisiteTypeMemory = "memory"
+
+	// PrerequisiteTypeFile requires a file to exist in the workspace
+	PrerequisiteTypeFile = "file"
+
+	// PrerequisiteTypeCommand requires a command to be on the PATH
+	PrerequisiteTypeCommand = "command"
+
+	// PrerequisiteTypeTemplate requires another template to have been instantiated before
+	PrerequisiteTypeTemplate = "template"
+
+	// PrerequisiteTypeNote is a free text hint that cannot be checked automatically
+	PrerequisiteTypeNote = "note"
+)
+
+// Prerequisite is a condition that has to hold before a template is instantiated.
+// A plain string in YAML or JSON is a note.
+type Prerequisite struct {
+	Type        string `json:"type" yaml:"type"`
+	Project     string `json:"project,omitempty" yaml:"project,omitempty"`   // memory project
+	Path        string `json:"path,omitempty" yaml:"path,omitempty"`         // memory path or workspace file
+	Command     string `json:"command,omitempty" yaml:"command,omitempty"`   // command name
+	Template    string `json:"template,omitempty" yaml:"template,omitempty"` // template ID
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// prerequisite has the fields of Prerequisite without its custom encoding
+type prerequisite Prerequisite
+
+// isNote reports whether the prerequisite can be stored as a plain string
+func (p Prerequisite) isNote() bool {
+	return p.Type == PrerequisiteTypeNote && p.Project == "" && p.Path == "" && p.Command == "" && p.Template == ""
+}
+
+// MarshalJSON writes notes as plain strings
+func (p Prerequisite) MarshalJSON() ([]byte, error) {
+	if p.isNote() {
+		return json.Marshal(p.Description)
+	}
+	return json.Marshal(prerequisite(p))
+}
+
+// UnmarshalJSON accepts both plain strings and prerequisite objects
+func (p *Prerequisite) UnmarshalJSON(data []byte) error {
+	var description string
+	if err := json.Unmarshal(data, &description); err == nil {
+		*p = Prerequisite{Type: PrerequisiteTypeNote, Description: description}
+		return nil
+	}
+	return json.Unmarshal(data, (*prerequisite)(p))
+}
+
+// MarshalYAML writes notes as plain strings, like prerequisites were stored before
+func (p Prerequisite) MarshalYAML() (interface{}, error) {
+	if p.isNote() {
+		return p.Description, nil
+	}
+	return prerequisite(p), nil
+}
+
+// UnmarshalYAML accepts both plain strings and prerequisite mappings
+func (p *Prerequisite) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var description string
+	if err := unmarshal(&description); err == nil {
+		*p = Prerequisite{Type: PrerequisiteTypeNote, Description: description}
+		return nil
+	}
+	return unmarshal((*prerequisite)(p))
+}
+
+// Validate checks that the prerequisite has the fields its type needs
+func (p Prerequisite) Validate() error {
+	switch p.Type {
+	case PrerequisiteTypeMemory:
+		if p.Project == "" || p.Path == "" {
+			return fmt.Errorf("memory prerequisites need a project and a path")
+		}
+	case PrerequisiteTypeFile:
+		if p.Path == "" {
+			return fmt.Errorf("file prerequisites need a path")
+		}
+	case PrerequisiteTypeCommand:
+		if p.Command == "" {
+			return fmt.Errorf("command prerequisites need a command")
+		}
+	case PrerequisiteTypeTemplate:
+		if p.Template == "" {
+			return fmt.Errorf("template prerequisites need a template")
+		}
+	case PrerequisiteTypeNote:
+		if p.Description == "" {
+			return fmt.Errorf("note prerequisites need a description")
+		}
+	default:
+		return fmt.Errorf("unknown prerequisite type '%s', use memory, file, command, template or note", p.Type)
+	}
+	return nil
+}
+
+// String describes the prerequisite for reports
+func (p Prerequisite) String() string {
+	if p.Description != "" {
+		return p.Description
+	}
+	switch p.Type {
+	case PrerequisiteTypeMemory:
+		return fmt.Sprintf("memory '%s' exists in project '%s'", p.Path, p.Project)
+	case PrerequisiteTypeFile:
+		return fmt.Sprintf("file '%s' exists in the workspace", p.Path)
+	case PrerequisiteTypeCommand:
+		return fmt.Sprintf("command '%s' is on the PATH", p.Command)
+	case PrerequisiteTypeTemplate:
+		return fmt.Sprintf("template '%s' was instantiated", p.Template)
+	}
+	return p.Type
+}
+
+// referencedParameters returns the parameter names the prerequisite uses in placeholders
+func (p Prerequisite) referencedParameters() []string {
+	var names []string
+	for _, text := range []string{p.Project, p.Path, p.Command, p.Template} {
+		for _, match := range PlaceholderPattern.FindAllStringSubmatch(text, -1) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Resolve returns the prerequisite with placeholders replaced by parameter values
+func (p Prerequisite) Resolve(parameters map[string]string) Prerequisite {
+	p.Project = ResolvePlaceholders(p.Project, parameters)
+	p.Path = ResolvePlaceholders(p.Path, parameters)
+	p.Command = ResolvePlaceholders(p.Command, parameters)
+	p.Template = ResolvePlaceholders(p.Template, parameters)
+	return p
+}
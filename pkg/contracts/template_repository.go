@@ -1,6 +1,9 @@
 package contracts
 
-import "time"
+import (
+	"regexp"
+	"time"
+)
 
 // TaskTemplate represents a reusable task workflow template
 type TaskTemplate struct {
@@ -8,24 +11,101 @@ type TaskTemplate struct {
 	Name          string               `json:"name"`
 	Description   string               `json:"description"`
 	Parameters    map[string]Parameter `json:"parameters"`
-	Tasks         []string             `json:"tasks"`
-	Prerequisites []string             `json:"prerequisites,omitempty"`
+	Tasks         []TemplateTask       `json:"tasks"`
+	Prerequisites []Prerequisite       `json:"prerequisites,omitempty"`
+	Version       int                  `json:"version"` // revision number, starting at 1
 	CreatedAt     time.Time            `json:"created_at"`
 	UpdatedAt     time.Time            `json:"updated_at"`
 }
 
+// TemplateVersion summarizes a stored revision of a template
+type TemplateVersion struct {
+	Version     int       `json:"version"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Tasks       int       `json:"tasks"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PlaceholderPattern matches the ${name} placeholders of template tasks
+var PlaceholderPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// ResolvePlaceholders replaces the placeholders in text by parameter values.
+// Parameters without a value are replaced by an empty string.
+func ResolvePlaceholders(text string, parameters map[string]string) string {
+	return PlaceholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return parameters[match[2:len(match)-1]]
+	})
+}
+
+// ReferencedParameters returns the names of all parameters the tasks and prerequisites use, in order of first use
+func (t *TaskTemplate) ReferencedParameters() []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	add := func(referenced []string) {
+		for _, name := range referenced {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	for _, task := range t.Tasks {
+		add(task.ReferencedParameters())
+	}
+	for _, prerequisite := range t.Prerequisites {
+		add(prerequisite.referencedParameters())
+	}
+	return names
+}
+
+// ParametersWithDefaults returns the given values completed with the defaults of parameters without a value
+func (t *TaskTemplate) ParametersWithDefaults(values map[string]string) map[string]string {
+	resolved := make(map[string]string, len(t.Parameters))
+	for name, value := range values {
+		resolved[name] = value
+	}
+	for name, param := range t.Parameters {
+		if resolved[name] == "" && param.Default != "" {
+			resolved[name] = param.Default
+		}
+	}
+	return resolved
+}
+
+// Parameter types supported by templates
+const (
+	ParameterTypeString  = "string"
+	ParameterTypeEnum    = "enum"
+	ParameterTypeNumber  = "number"
+	ParameterTypeBoolean = "boolean"
+	ParameterTypeList    = "list" // comma-separated items
+)
+
 // Parameter defines a template parameter
 type Parameter struct {
-	Type        string   `json:"type"` // string, enum, number, boolean
+	Type        string   `json:"type"` // string, enum, number, boolean, list
 	Description string   `json:"description"`
 	Required    bool     `json:"required"`
 	Default     string   `json:"default,omitempty"`
-	Values      []string `json:"values,omitempty"` // for enum type
+	Values      []string `json:"values,omitempty"` // for enum type, and allowed items of list type
+
+	// Min and Max bound number values
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+
+	// Pattern is a regular expression string values and list items must match completely
+	Pattern string `json:"pattern,omitempty"`
+
+	// MinLength and MaxLength bound the characters of string values and the items of list values
+	MinLength *int `json:"min_length,omitempty"`
+	MaxLength *int `json:"max_length,omitempty"`
 }
 
 // TemplateInstance represents an instantiated template with resolved parameters
 type TemplateInstance struct {
 	TemplateID string            `json:"template_id"`
+	Version    int               `json:"version"`
 	Parameters map[string]string `json:"parameters"`
 	Tasks      []string          `json:"tasks"`
 }
@@ -41,15 +121,33 @@ type TaskTemplateRepository interface {
 	// ListTemplates lists all templates
 	ListTemplates() ([]*TaskTemplate, error)
 
-	// UpdateTemplate updates an existing template
+	// UpdateTemplate stores a template as the next version of an existing one, keeping the previous versions
 	UpdateTemplate(template *TaskTemplate) error
 
-	// DeleteTemplate deletes a template by ID
+	// ListTemplateVersions returns all stored versions of a template, oldest first
+	ListTemplateVersions(id string) ([]*TemplateVersion, error)
+
+	// GetTemplateVersion retrieves a specific version of a template, or the current one for version 0
+	GetTemplateVersion(id string, version int) (*TaskTemplate, error)
+
+	// RollbackTemplate restores a previous version of a template as its new current version
+	RollbackTemplate(id string, version int) (*TaskTemplate, error)
+
+	// DeleteTemplate deletes a template and its versions by ID
 	DeleteTemplate(id string) error
 
 	// InstantiateTemplate creates a template instance with resolved parameters
 	InstantiateTemplate(templateID string, parameters map[string]string) (*TemplateInstance, error)
 
+	// InstantiateTemplateVersion creates a template instance from a specific version, or the current one for version 0
+	InstantiateTemplateVersion(templateID string, version int, parameters map[string]string) (*TemplateInstance, error)
+
+	// RecordInstantiation remembers that tasks were created from a template
+	RecordInstantiation(templateID string) error
+
+	// LastInstantiation returns when tasks were last created from a template, or nil if never
+	LastInstantiation(templateID string) (*time.Time, error)
+
 	// Close closes the repository and cleans up resources
 	Close() error
 }
@@ -0,0 +1,189 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TemplateItemPlaceholder is the name under which for_each tasks see the current item, as ${item}
+const TemplateItemPlaceholder = "item"
+
+// MaxTemplateIncludeDepth is the number of levels templates may be nested through includes
+const MaxTemplateIncludeDepth = 8
+
+// TemplateTask is a task of a template. A plain string in YAML or JSON is a task that is always added.
+type TemplateTask struct {
+	Content string `json:"content" yaml:"content"`
+
+	// When only adds the task if the condition on a parameter holds:
+	// "name" (true or non-empty), "!name", "name == value" or "name != value"
+	When string `json:"when,omitempty" yaml:"when,omitempty"`
+
+	// ForEach adds the task once per item of the named list parameter
+	ForEach string `json:"for_each,omitempty" yaml:"for_each,omitempty"`
+
+	// Include adds the tasks of the template with this ID instead of Content
+	Include string `json:"include,omitempty" yaml:"include,omitempty"`
+
+	// With maps parameters of the included template to values, which may use ${name} placeholders
+	With map[string]string `json:"with,omitempty" yaml:"with,omitempty"`
+}
+
+// templateTask has the fields of TemplateTask without its custom encoding
+type templateTask TemplateTask
+
+// TemplateTasksFromContents creates unconditional template tasks from plain descriptions
+func TemplateTasksFromContents(contents []string) []TemplateTask {
+	tasks := make([]TemplateTask, len(contents))
+	for i, content := range contents {
+		tasks[i] = TemplateTask{Content: content}
+	}
+	return tasks
+}
+
+// isPlain reports whether the task can be stored as a plain string
+func (t TemplateTask) isPlain() bool {
+	return t.When == "" && t.ForEach == "" && t.Include == ""
+}
+
+// MarshalJSON writes tasks without conditions as plain strings
+func (t TemplateTask) MarshalJSON() ([]byte, error) {
+	if t.isPlain() {
+		return json.Marshal(t.Content)
+	}
+	return json.Marshal(templateTask(t))
+}
+
+// UnmarshalJSON accepts both plain strings and task objects
+func (t *TemplateTask) UnmarshalJSON(data []byte) error {
+	var content string
+	if err := json.Unmarshal(data, &content); err == nil {
+		*t = TemplateTask{Content: content}
+		return nil
+	}
+	return json.Unmarshal(data, (*templateTask)(t))
+}
+
+// MarshalYAML writes tasks without conditions as plain strings, like templates were stored before
+func (t TemplateTask) MarshalYAML() (interface{}, error) {
+	if t.isPlain() {
+		return t.Content, nil
+	}
+	return templateTask(t), nil
+}
+
+// UnmarshalYAML accepts both plain strings and task mappings
+func (t *TemplateTask) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var content string
+	if err := unmarshal(&content); err == nil {
+		*t = TemplateTask{Content: content}
+		return nil
+	}
+	return unmarshal((*templateTask)(t))
+}
+
+// Condition splits the when condition into the parameter name, the operator and the compared value.
+// The operator is "" for truthiness checks and "!" for negated ones.
+func (t TemplateTask) Condition() (name string, operator string, value string, err error) {
+	condition := strings.TrimSpace(t.When)
+	for _, op := range []string{"==", "!="} {
+		if left, right, found := strings.Cut(condition, op); found {
+			name = strings.TrimSpace(left)
+			value = strings.Trim(strings.TrimSpace(right), `"'`)
+			if name == "" {
+				return "", "", "", fmt.Errorf("invalid condition '%s': missing parameter name", t.When)
+			}
+			return name, op, value, nil
+		}
+	}
+
+	if negated, found := strings.CutPrefix(condition, "!"); found {
+		operator, condition = "!", strings.TrimSpace(negated)
+	}
+	if condition == "" || strings.ContainsAny(condition, " \t=<>") {
+		return "", "", "", fmt.Errorf("invalid condition '%s': use 'name', '!name', 'name == value' or 'name != value'", t.When)
+	}
+	return condition, operator, "", nil
+}
+
+// Applies evaluates the when condition against resolved parameter values
+func (t TemplateTask) Applies(parameters map[string]string) (bool, error) {
+	if t.When == "" {
+		return true, nil
+	}
+
+	name, operator, value, err := t.Condition()
+	if err != nil {
+		return false, err
+	}
+
+	switch operator {
+	case "==":
+		return parameters[name] == value, nil
+	case "!=":
+		return parameters[name] != value, nil
+	case "!":
+		return !isTruthy(parameters[name]), nil
+	default:
+		return isTruthy(parameters[name]), nil
+	}
+}
+
+// ReferencedParameters returns the parameter names the task uses in its content, condition, loop and include mapping
+func (t TemplateTask) ReferencedParameters() []string {
+	var names []string
+	if t.When != "" {
+		if name, _, _, err := t.Condition(); err == nil {
+			names = append(names, name)
+		}
+	}
+	if t.ForEach != "" {
+		names = append(names, t.ForEach)
+	}
+
+	texts := []string{t.Content}
+	for _, name := range sortedKeys(t.With) {
+		texts = append(texts, t.With[name])
+	}
+	for _, text := range texts {
+		for _, match := range PlaceholderPattern.FindAllStringSubmatch(text, -1) {
+			if t.ForEach != "" && match[1] == TemplateItemPlaceholder {
+				continue
+			}
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// sortedKeys returns the keys of a map in a stable order
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isTruthy reports whether a parameter value counts as set: booleans by their value, anything else when non-empty
+func isTruthy(value string) bool {
+	if parsed, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+		return parsed
+	}
+	return strings.TrimSpace(value) != ""
+}
+
+// SplitListValue splits a comma-separated list value into its trimmed, non-empty items
+func SplitListValue(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,29 @@
+// Package textsearch holds the word handling shared by the full-text searches of the repositories
+package textsearch
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize splits text into lowercase words.
+// The knowledge search index and the hashing embeddings are built from its words,
+// so changes must bump the index version and the name of the hashing provider.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// UniqueTerms removes duplicate terms while keeping their order
+func UniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := []string{}
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,28 @@
+package textsearch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := map[string][]string{
+		"Deploy to Staging?":      {"deploy", "to", "staging"},
+		"Postgres 16, not MySQL!": {"postgres", "16", "not", "mysql"},
+		"Größe_ändern":            {"größe", "ändern"},
+		"  --- ":                  {},
+	}
+
+	for text, expected := range tests {
+		if words := Tokenize(text); !reflect.DeepEqual(words, expected) {
+			t.Errorf("Tokenize(%q) = %v, expected %v", text, words, expected)
+		}
+	}
+}
+
+func TestUniqueTerms(t *testing.T) {
+	unique := UniqueTerms([]string{"deploy", "staging", "deploy", "now", "staging"})
+	if expected := []string{"deploy", "staging", "now"}; !reflect.DeepEqual(unique, expected) {
+		t.Errorf("Expected %v, got %v", expected, unique)
+	}
+}
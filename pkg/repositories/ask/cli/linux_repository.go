@@ -1,17 +1,99 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"os/exec"
 	"strings"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
 )
 
 type LinuxRepository struct{}
 
-func (r *LinuxRepository) Ask(question string) (string, error) {
-	cmd := exec.Command("zenity", "--entry", "--text", question)
-	output, err := cmd.CombinedOutput()
+// zenitySeparator separates the picked choices in the output of multi select lists
+const zenitySeparator = "\n"
+
+// zenityCancelled is the exit code of zenity when the dialog was closed or cancelled
+const zenityCancelled = 1
+
+func (r *LinuxRepository) Ask(ctx context.Context, question contracts.Question) (*contracts.Answer, error) {
+	// The dialog is killed once ctx is done, so no zenity process is left behind
+	cmd := exec.CommandContext(ctx, "zenity", zenityArgs(question)...)
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// zenity --question reports "no" with the same exit code as closing other dialogs
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == zenityCancelled {
+		if question.Confirm {
+			return contracts.NewConfirmAnswer(false), nil
+		}
+		return nil, contracts.ErrQuestionDismissed
+	}
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	return parseZenityOutput(question, string(output))
+}
+
+// zenityArgs returns the zenity arguments showing the dialog for a question
+func zenityArgs(question contracts.Question) []string {
+	if question.Confirm {
+		args := []string{"--question", "--text", question.Text}
+		if confirmed, err := contracts.ParseConfirmation(question.Default); err == nil && !confirmed {
+			args = append(args, "--default-cancel")
+		}
+		return args
+	}
+
+	if len(question.Choices) == 0 {
+		args := []string{"--entry", "--text", question.Text}
+		if question.Default != "" {
+			args = append(args, "--entry-text", question.Default)
+		}
+		return args
+	}
+
+	// Radio and check lists can preselect the default choices
+	args := []string{"--list", "--text", question.Text, "--column", "", "--column", "Choice", "--hide-header"}
+	if question.MultiSelect {
+		args = append(args, "--checklist", "--multiple", "--separator", zenitySeparator)
+	} else {
+		args = append(args, "--radiolist")
+	}
+	defaults := question.DefaultChoices()
+	for _, choice := range question.Choices {
+		selected := "FALSE"
+		for _, item := range defaults {
+			if item == choice {
+				selected = "TRUE"
+			}
+		}
+		args = append(args, selected, choice)
+	}
+	return args
+}
+
+// parseZenityOutput turns the output of a zenity dialog into the answer
+func parseZenityOutput(question contracts.Question, output string) (*contracts.Answer, error) {
+	if question.Confirm {
+		return contracts.NewConfirmAnswer(true), nil
+	}
+
+	output = strings.TrimRight(output, "\r\n")
+	if len(question.Choices) == 0 {
+		return &contracts.Answer{Text: strings.TrimSpace(output)}, nil
+	}
+
+	selected := []string{}
+	for _, choice := range strings.Split(output, zenitySeparator) {
+		if choice != "" {
+			selected = append(selected, choice)
+		}
 	}
-	return strings.TrimSpace(string(output)), nil
+	return contracts.NewChoiceAnswer(question, selected)
 }
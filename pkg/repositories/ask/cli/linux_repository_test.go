@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+func TestZenityArgs(t *testing.T) {
+	tests := map[string]struct {
+		question contracts.Question
+		expected string
+	}{
+		"entry":         {contracts.Question{Text: "Name?", Default: "brain"}, "--entry --text Name? --entry-text brain"},
+		"confirm":       {contracts.Question{Text: "Sure?", Confirm: true}, "--question --text Sure?"},
+		"confirm no":    {contracts.Question{Text: "Sure?", Confirm: true, Default: "no"}, "--question --text Sure? --default-cancel"},
+		"single choice": {contracts.Question{Text: "Which?", Choices: []string{"a", "b"}, Default: "b"}, "--list --text Which? --column  --column Choice --hide-header --radiolist FALSE a TRUE b"},
+		"multi choice":  {contracts.Question{Text: "Which?", Choices: []string{"a", "b"}, MultiSelect: true}, "--list --text Which? --column  --column Choice --hide-header --checklist --multiple --separator \n FALSE a FALSE b"},
+	}
+	for name, test := range tests {
+		if args := strings.Join(zenityArgs(test.question), " "); args != test.expected {
+			t.Errorf("%s: expected %q, got %q", name, test.expected, args)
+		}
+	}
+}
+
+func TestParseZenityOutput(t *testing.T) {
+	multi := contracts.Question{Text: "Which?", Choices: []string{"a, b", "c"}, MultiSelect: true}
+	answer, err := parseZenityOutput(multi, "a, b\nc\n")
+	if err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if len(answer.Selected) != 2 || answer.Selected[0] != "a, b" || answer.Text != "" {
+		t.Errorf("Expected both choices, got %+v", answer)
+	}
+
+	single := contracts.Question{Text: "Which?", Choices: []string{"a", "b"}}
+	answer, err = parseZenityOutput(single, "b\n")
+	if err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if answer.Text != "b" {
+		t.Errorf("Expected the picked choice as answer, got %+v", answer)
+	}
+
+	if _, err := parseZenityOutput(single, "z\n"); err == nil {
+		t.Error("Expected unknown choices to be rejected")
+	}
+}
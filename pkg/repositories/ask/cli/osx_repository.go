@@ -1,18 +1,100 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
 )
 
 type OsxRepository struct{}
 
-func (r *OsxRepository) Ask(question string) (string, error) {
-	cmd := exec.Command("osascript", "-e", fmt.Sprintf("text returned of (display dialog \"%s\" default answer \"\")", strings.ReplaceAll(question, "\"", "\\\"")))
+// osascriptCancelled is the AppleScript error number of cancelled dialogs
+const osascriptCancelled = "(-128)"
+
+func (r *OsxRepository) Ask(ctx context.Context, question contracts.Question) (*contracts.Answer, error) {
+	args := []string{}
+	for _, line := range osascriptLines(question) {
+		args = append(args, "-e", line)
+	}
+	// The dialog is killed once ctx is done, so no osascript process is left behind
+	cmd := exec.CommandContext(ctx, "osascript", args...)
 	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil && strings.Contains(string(output), osascriptCancelled) {
+		return nil, contracts.ErrQuestionDismissed
+	}
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	return parseOsascriptOutput(question, string(output))
+}
+
+// osascriptLines returns the AppleScript showing the dialog for a question
+func osascriptLines(question contracts.Question) []string {
+	if question.Confirm {
+		defaultButton := "Yes"
+		if confirmed, err := contracts.ParseConfirmation(question.Default); err == nil && !confirmed {
+			defaultButton = "No"
+		}
+		return []string{fmt.Sprintf("button returned of (display dialog %s buttons {\"No\", \"Yes\"} default button \"%s\")", appleScriptString(question.Text), defaultButton)}
+	}
+
+	if len(question.Choices) == 0 {
+		return []string{fmt.Sprintf("text returned of (display dialog %s default answer %s)", appleScriptString(question.Text), appleScriptString(question.Default))}
+	}
+
+	// Picked choices are returned one per line, so choices containing commas stay intact
+	choose := fmt.Sprintf("set answer to choose from list %s with prompt %s", appleScriptList(question.Choices), appleScriptString(question.Text))
+	if defaults := question.DefaultChoices(); len(defaults) > 0 {
+		choose += " default items " + appleScriptList(defaults)
+	}
+	if question.MultiSelect {
+		choose += " with multiple selections allowed"
+	}
+	return []string{
+		choose,
+		"if answer is false then error number -128",
+		"set AppleScript's text item delimiters to linefeed",
+		"return answer as text",
+	}
+}
+
+// parseOsascriptOutput turns the output of an AppleScript dialog into the answer
+func parseOsascriptOutput(question contracts.Question, output string) (*contracts.Answer, error) {
+	output = strings.TrimRight(output, "\r\n")
+	if question.Confirm {
+		return contracts.NewConfirmAnswer(output == "Yes"), nil
+	}
+
+	if len(question.Choices) == 0 {
+		return &contracts.Answer{Text: strings.TrimSpace(output)}, nil
+	}
+
+	selected := []string{}
+	for _, choice := range strings.Split(output, "\n") {
+		if choice != "" {
+			selected = append(selected, choice)
+		}
+	}
+	return contracts.NewChoiceAnswer(question, selected)
+}
+
+// appleScriptString quotes a string for AppleScript
+func appleScriptString(value string) string {
+	return "\"" + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + "\""
+}
+
+// appleScriptList quotes strings as an AppleScript list
+func appleScriptList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = appleScriptString(value)
 	}
-	return strings.TrimSpace(string(output)), nil
+	return "{" + strings.Join(quoted, ", ") + "}"
 }
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+func TestOsascriptLines(t *testing.T) {
+	lines := osascriptLines(contracts.Question{Text: `Say "hi"`})
+	if len(lines) != 1 || !strings.Contains(lines[0], `display dialog "Say \"hi\"" default answer ""`) {
+		t.Errorf("Expected an escaped entry dialog, got %v", lines)
+	}
+
+	lines = osascriptLines(contracts.Question{Text: "Sure?", Confirm: true, Default: "no"})
+	if len(lines) != 1 || !strings.Contains(lines[0], `buttons {"No", "Yes"} default button "No"`) {
+		t.Errorf("Expected a yes/no dialog defaulting to no, got %v", lines)
+	}
+
+	lines = osascriptLines(contracts.Question{Text: "Which?", Choices: []string{"a", "b"}, MultiSelect: true, Default: "b"})
+	if !strings.Contains(lines[0], `choose from list {"a", "b"} with prompt "Which?" default items {"b"} with multiple selections allowed`) {
+		t.Errorf("Expected a multi select list, got %v", lines)
+	}
+}
+
+func TestParseOsascriptOutput(t *testing.T) {
+	answer, err := parseOsascriptOutput(contracts.Question{Text: "Sure?", Confirm: true}, "No\n")
+	if err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if answer.Confirmed == nil || *answer.Confirmed {
+		t.Errorf("Expected a declined confirmation, got %+v", answer)
+	}
+
+	answer, err = parseOsascriptOutput(contracts.Question{Text: "Which?", Choices: []string{"a", "b"}, MultiSelect: true}, "a\nb\n")
+	if err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if len(answer.Selected) != 2 {
+		t.Errorf("Expected both choices, got %+v", answer)
+	}
+}
@@ -0,0 +1,198 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// PollInterval is how often the repository checks for an answer file
+const PollInterval = 200 * time.Millisecond
+
+// Repository asks questions through files, so any process or person with access to the directory can answer.
+// Every question is written to <id>.question.json; the answer is read from <id>.answer once it appears,
+// either as text (like typed on a terminal) or as a JSON form like the web page submits.
+// The answer file may also be a named pipe; its answer is complete once the responder closes it.
+// Answers that don't fit the question leave it open: the question file gets an error, the answer file
+// is moved to <id>.answer.rejected and a corrected answer can be written.
+type Repository struct {
+	dir    string
+	nextID int
+	mutex  sync.Mutex
+}
+
+// NewRepository creates a repository exchanging questions and answers in dir
+func NewRepository(dir string) (*Repository, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create questions directory: %w", err)
+	}
+	return &Repository{dir: dir}, nil
+}
+
+// questionFile is the content of a question file
+type questionFile struct {
+	ID         string `json:"id"`
+	AnswerFile string `json:"answer_file"`
+	Error      string `json:"error,omitempty"` // why the last answer was rejected
+	contracts.Question
+}
+
+// Ask writes the question file and waits for the answer file, removing the question once ctx is done
+func (r *Repository) Ask(ctx context.Context, question contracts.Question) (*contracts.Answer, error) {
+	id := r.questionID()
+	questionPath := filepath.Join(r.dir, id+".question.json")
+	answerPath := filepath.Join(r.dir, id+".answer")
+	rejectedPath := answerPath + ".rejected"
+
+	asked := questionFile{ID: id, AnswerFile: answerPath, Question: question}
+	if err := writeQuestion(questionPath, asked); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.Remove(questionPath)
+		_ = os.Remove(answerPath)
+		_ = os.Remove(rejectedPath)
+	}()
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for {
+		text, err := readAnswer(ctx, answerPath)
+		if err != nil {
+			return nil, err
+		}
+		if text != "" {
+			answer, parseErr := parseAnswer(question, text)
+			if parseErr == nil {
+				return answer, nil
+			}
+
+			// The question stays open, so the responder can correct the answer
+			if err := rejectAnswer(answerPath, rejectedPath); err != nil {
+				return nil, err
+			}
+			asked.Error = parseErr.Error()
+			if err := writeQuestion(questionPath, asked); err != nil {
+				return nil, err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// questionID returns a unique ID for the next question
+func (r *Repository) questionID() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.nextID++
+	return fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), r.nextID)
+}
+
+// writeQuestion writes the question file
+func writeQuestion(questionPath string, question questionFile) error {
+	data, err := json.MarshalIndent(question, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal question: %w", err)
+	}
+	if err := os.WriteFile(questionPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write question file: %w", err)
+	}
+	return nil
+}
+
+// readAnswer reads the answer file, returning an empty text while there is no complete answer yet.
+// Answers are complete once they end with a newline, so half-written files aren't read.
+func readAnswer(ctx context.Context, answerPath string) (string, error) {
+	info, err := os.Stat(answerPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer file: %w", err)
+	}
+
+	if info.Mode()&os.ModeNamedPipe != 0 {
+		// A writer that closed the pipe without an answer leaves the question open
+		data, err := readPipe(ctx, answerPath)
+		return string(data), err
+	}
+
+	data, err := os.ReadFile(answerPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer file: %w", err)
+	}
+	text := string(data)
+	if !strings.HasSuffix(text, "\n") {
+		return "", nil
+	}
+	return text, nil
+}
+
+// rejectAnswer moves a rejected answer file aside, so it isn't read again.
+// Named pipes are kept: their answer is gone once read and they can be written again.
+func rejectAnswer(answerPath string, rejectedPath string) error {
+	info, err := os.Stat(answerPath)
+	if os.IsNotExist(err) || (err == nil && info.Mode()&os.ModeNamedPipe != 0) {
+		return nil
+	}
+	if err := os.Rename(answerPath, rejectedPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to reject answer file: %w", err)
+	}
+	return nil
+}
+
+// readPipe reads a named pipe until its writer closes it. Reading blocks until
+// someone writes to the pipe, so it happens in the background to stop with ctx.
+func readPipe(ctx context.Context, pipePath string) ([]byte, error) {
+	type readResult struct {
+		data []byte
+		err  error
+	}
+	results := make(chan readResult, 1)
+	go func() {
+		data, err := os.ReadFile(pipePath)
+		results <- readResult{data: data, err: err}
+	}()
+
+	select {
+	case result := <-results:
+		if result.err != nil {
+			return nil, fmt.Errorf("failed to read answer pipe: %w", result.err)
+		}
+		return result.data, nil
+	case <-ctx.Done():
+		// Opening the pipe for writing releases the blocked reader with an end of file.
+		// Without O_NONBLOCK the open would hang if the reader is already gone.
+		if writer, err := os.OpenFile(pipePath, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+			_ = writer.Close()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// parseAnswer reads an answer given as text or as a JSON form
+func parseAnswer(question contracts.Question, text string) (*contracts.Answer, error) {
+	if trimmed := strings.TrimSpace(text); strings.HasPrefix(trimmed, "{") {
+		var form contracts.FormAnswer
+		if err := json.Unmarshal([]byte(trimmed), &form); err != nil {
+			return nil, fmt.Errorf("invalid answer JSON: %w", err)
+		}
+		return contracts.ParseFormAnswer(question, form)
+	}
+	return contracts.ParseTextAnswer(question, text)
+}
@@ -0,0 +1,171 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// nextQuestion waits for a question file in dir and returns it, or false if none appears
+func nextQuestion(dir string) (questionFile, bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.question.json"))
+		if len(matches) > 0 {
+			data, err := os.ReadFile(matches[0])
+			var question questionFile
+			if err == nil && json.Unmarshal(data, &question) == nil {
+				return question, true
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return questionFile{}, false
+}
+
+// answerNextQuestion waits for a question file in dir and answers it with the given content
+func answerNextQuestion(t *testing.T, dir string, content string) <-chan questionFile {
+	asked := make(chan questionFile, 1)
+	go func() {
+		question, ok := nextQuestion(dir)
+		if !ok {
+			close(asked)
+			return
+		}
+		asked <- question
+		_ = os.WriteFile(question.AnswerFile, []byte(content), 0644)
+	}()
+	return asked
+}
+
+func TestRepository(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	t.Run("text answers", func(t *testing.T) {
+		asked := answerNextQuestion(t, dir, "2\n")
+		answer, err := repo.Ask(context.Background(), contracts.Question{Text: "Which database?", Choices: []string{"SQLite", "Postgres"}})
+		if err != nil {
+			t.Fatalf("Failed to ask: %v", err)
+		}
+		if answer.Text != "Postgres" {
+			t.Errorf("Expected Postgres, got %+v", answer)
+		}
+		if question := <-asked; question.Text != "Which database?" || len(question.Choices) != 2 {
+			t.Errorf("Expected the question to be written, got %+v", question)
+		}
+	})
+
+	t.Run("JSON answers", func(t *testing.T) {
+		answerNextQuestion(t, dir, `{"confirmed": true}`+"\n")
+		answer, err := repo.Ask(context.Background(), contracts.Question{Text: "Deploy?", Confirm: true})
+		if err != nil {
+			t.Fatalf("Failed to ask: %v", err)
+		}
+		if answer.Confirmed == nil || !*answer.Confirmed {
+			t.Errorf("Expected a confirmation, got %+v", answer)
+		}
+	})
+
+	t.Run("rejected answers keep the question open", func(t *testing.T) {
+		for _, tc := range []struct {
+			name     string
+			question contracts.Question
+			rejected string
+			reason   string
+			answer   string
+			expected string
+		}{
+			{"unknown choice", contracts.Question{Text: "Which database?", Choices: []string{"SQLite", "Postgres"}}, `{"answer": "MySQL"}`, "MySQL", `{"answer": "SQLite"}`, "SQLite"},
+			{"confirmation without a value", contracts.Question{Text: "Deploy?", Confirm: true}, `{"answer": "yes"}`, "confirm", "yes", ""},
+			{"invalid JSON", contracts.Question{Text: "Name?"}, `{"answer": `, "invalid answer JSON", "Alice", "Alice"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				responded := make(chan questionFile, 1)
+				go func() {
+					defer close(responded)
+					question, ok := nextQuestion(dir)
+					if !ok {
+						return
+					}
+					_ = os.WriteFile(question.AnswerFile, []byte(tc.rejected+"\n"), 0644)
+
+					// The question file reports the problem once the answer is rejected
+					deadline := time.Now().Add(5 * time.Second)
+					for time.Now().Before(deadline) {
+						if rejected, ok := nextQuestion(dir); ok && rejected.Error != "" {
+							if _, err := os.Stat(question.AnswerFile + ".rejected"); err == nil {
+								responded <- rejected
+								_ = os.WriteFile(question.AnswerFile, []byte(tc.answer+"\n"), 0644)
+								return
+							}
+						}
+						time.Sleep(10 * time.Millisecond)
+					}
+				}()
+
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				answer, err := repo.Ask(ctx, tc.question)
+				if err != nil {
+					t.Fatalf("Expected the question to stay open for a corrected answer, got: %v", err)
+				}
+				rejected, ok := <-responded
+				if !ok {
+					t.Fatal("Expected the rejected answer to be reported in the question file")
+				}
+				if !strings.Contains(rejected.Error, tc.reason) {
+					t.Errorf("Expected the error to mention %q, got: %s", tc.reason, rejected.Error)
+				}
+				if tc.question.Confirm && (answer.Confirmed == nil || !*answer.Confirmed) {
+					t.Errorf("Expected the corrected confirmation, got %+v", answer)
+				} else if !tc.question.Confirm && answer.Text != tc.expected {
+					t.Errorf("Expected the corrected answer %q, got %+v", tc.expected, answer)
+				}
+			})
+		}
+	})
+
+	t.Run("JSON answers can't set result flags", func(t *testing.T) {
+		answerNextQuestion(t, dir, `{"answer": "Alice", "timed_out": true, "reused": true}`+"\n")
+		answer, err := repo.Ask(context.Background(), contracts.Question{Text: "Name?"})
+		if err != nil {
+			t.Fatalf("Failed to ask: %v", err)
+		}
+		if answer.Text != "Alice" || answer.TimedOut || answer.Reused {
+			t.Errorf("Expected only the answer to be taken, got %+v", answer)
+		}
+	})
+
+	t.Run("unanswered questions end with the context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := repo.Ask(ctx, contracts.Question{Text: "Anyone there?"}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the deadline to end the question, got %v", err)
+		}
+	})
+
+	t.Run("files are cleaned up", func(t *testing.T) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("Failed to read directory: %v", err)
+		}
+		names := []string{}
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if len(names) != 0 {
+			t.Errorf("Expected no leftover files, got %s", strings.Join(names, ", "))
+		}
+	})
+}
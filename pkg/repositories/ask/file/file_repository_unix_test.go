@@ -0,0 +1,78 @@
+//go:build unix
+
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// pipeNextQuestion waits for a question file in dir and creates its answer file as a named pipe
+func pipeNextQuestion(dir string) <-chan string {
+	created := make(chan string, 1)
+	go func() {
+		question, ok := nextQuestion(dir)
+		if ok && syscall.Mkfifo(question.AnswerFile, 0644) == nil {
+			created <- question.AnswerFile
+		}
+		close(created)
+	}()
+	return created
+}
+
+func TestRepositoryNamedPipes(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	t.Run("answers written to the pipe", func(t *testing.T) {
+		created := pipeNextQuestion(dir)
+		go func() {
+			if pipePath, ok := <-created; ok {
+				_ = os.WriteFile(pipePath, []byte("yes"), 0644)
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		answer, err := repo.Ask(ctx, contracts.Question{Text: "Deploy?", Confirm: true})
+		if err != nil {
+			t.Fatalf("Failed to ask: %v", err)
+		}
+		if answer.Confirmed == nil || !*answer.Confirmed {
+			t.Errorf("Expected a confirmation, got %+v", answer)
+		}
+	})
+
+	t.Run("unanswered pipes end with the context", func(t *testing.T) {
+		created := pipeNextQuestion(dir)
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		results := make(chan error, 1)
+		go func() {
+			_, err := repo.Ask(ctx, contracts.Question{Text: "Anyone there?"})
+			results <- err
+		}()
+
+		if _, ok := <-created; !ok {
+			t.Fatal("Failed to create the answer pipe")
+		}
+		select {
+		case err := <-results:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected the deadline to end the question, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the deadline to end the question, but it still waits for the pipe")
+		}
+	})
+}
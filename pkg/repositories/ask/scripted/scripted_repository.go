@@ -0,0 +1,72 @@
+package scripted
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+	"gopkg.in/yaml.v3"
+)
+
+// ScriptedAnswer is an answer prepared in an answers file
+type ScriptedAnswer struct {
+	// Question only lets the answer be used for questions containing this text, ignoring case
+	Question string `json:"question,omitempty" yaml:"question,omitempty"`
+
+	// Answer is the text the user would have typed: text, choices or yes/no
+	Answer string `json:"answer" yaml:"answer"`
+}
+
+// Repository answers questions from a file of prepared answers, for tests and CI.
+// Every answer is used once, in file order, for the first question it matches.
+type Repository struct {
+	answers []ScriptedAnswer
+	used    []bool
+	mutex   sync.Mutex
+}
+
+// NewRepository reads the answers from a YAML (or JSON) file with a list of question/answer entries
+func NewRepository(path string) (*Repository, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers file: %w", err)
+	}
+
+	var answers []ScriptedAnswer
+	if err := yaml.Unmarshal(data, &answers); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal answers: %w", err)
+	}
+
+	return NewRepositoryFromAnswers(answers), nil
+}
+
+// NewRepositoryFromAnswers creates a repository answering with the given answers
+func NewRepositoryFromAnswers(answers []ScriptedAnswer) *Repository {
+	return &Repository{
+		answers: answers,
+		used:    make([]bool, len(answers)),
+	}
+}
+
+// Ask answers with the first unused answer matching the question
+func (r *Repository) Ask(ctx context.Context, question contracts.Question) (*contracts.Answer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, scripted := range r.answers {
+		if r.used[i] || !strings.Contains(strings.ToLower(question.Text), strings.ToLower(scripted.Question)) {
+			continue
+		}
+		r.used[i] = true
+		return contracts.ParseTextAnswer(question, scripted.Answer)
+	}
+
+	return nil, fmt.Errorf("no scripted answer left for question '%s'", question.Text)
+}
@@ -0,0 +1,56 @@
+package scripted
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+func TestRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.yaml")
+	answers := `
+- question: deploy
+  answer: "yes"
+- question: which services
+  answer: api, web
+- answer: first free text
+`
+	if err := os.WriteFile(path, []byte(answers), 0644); err != nil {
+		t.Fatalf("Failed to write answers: %v", err)
+	}
+	repo, err := NewRepository(path)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	answer, err := repo.Ask(context.Background(), contracts.Question{Text: "Which services?", Choices: []string{"api", "web", "worker"}, MultiSelect: true})
+	if err != nil {
+		t.Fatalf("Failed to ask: %v", err)
+	}
+	if len(answer.Selected) != 2 {
+		t.Errorf("Expected the matching answer, got %+v", answer)
+	}
+
+	answer, err = repo.Ask(context.Background(), contracts.Question{Text: "What is the name?"})
+	if err != nil {
+		t.Fatalf("Failed to ask: %v", err)
+	}
+	if answer.Text != "first free text" {
+		t.Errorf("Expected the unrestricted answer, got %+v", answer)
+	}
+
+	answer, err = repo.Ask(context.Background(), contracts.Question{Text: "Deploy now?", Confirm: true})
+	if err != nil {
+		t.Fatalf("Failed to ask: %v", err)
+	}
+	if answer.Confirmed == nil || !*answer.Confirmed {
+		t.Errorf("Expected a confirmation, got %+v", answer)
+	}
+
+	if _, err := repo.Ask(context.Background(), contracts.Question{Text: "Anything else?"}); err == nil {
+		t.Error("Expected an error once all answers are used")
+	}
+}
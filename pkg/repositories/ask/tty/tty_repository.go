@@ -0,0 +1,120 @@
+package tty
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// DefaultDevice is the controlling terminal of the process
+const DefaultDevice = "/dev/tty"
+
+// Repository asks questions on a terminal, for machines without a display.
+// The stdio transport occupies standard input and output, so the terminal is opened separately.
+type Repository struct {
+	device string
+	mutex  sync.Mutex
+}
+
+// NewRepository creates a repository asking on the terminal device, e.g. /dev/pts/3
+func NewRepository(device string) (*Repository, error) {
+	if device == "" {
+		device = DefaultDevice
+	}
+
+	terminal, err := os.OpenFile(device, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terminal %s: %w", device, err)
+	}
+	_ = terminal.Close()
+
+	return &Repository{device: device}, nil
+}
+
+// Ask prints the question on the terminal and reads the answer line, asking again until it is valid.
+// The terminal is closed once ctx is done, which ends a pending read.
+func (r *Repository) Ask(ctx context.Context, question contracts.Question) (*contracts.Answer, error) {
+	// Only one question at a time can own the terminal
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	terminal, err := os.OpenFile(r.device, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terminal %s: %w", r.device, err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = terminal.Close() })
+	defer func() {
+		if stop() {
+			_ = terminal.Close()
+		}
+	}()
+
+	answer, err := Prompt(question, terminal, terminal)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return answer, err
+}
+
+// Prompt writes the question to out and reads answer lines from in until one is valid
+func Prompt(question contracts.Question, in io.Reader, out io.Writer) (*contracts.Answer, error) {
+	reader := bufio.NewReader(in)
+	for {
+		if _, err := io.WriteString(out, FormatQuestion(question)); err != nil {
+			return nil, fmt.Errorf("failed to write question: %w", err)
+		}
+
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		answer, parseErr := contracts.ParseTextAnswer(question, line)
+		if parseErr == nil {
+			return answer, nil
+		}
+		if err == io.EOF {
+			return nil, parseErr
+		}
+		if _, err := fmt.Fprintf(out, "Invalid answer: %v\n", parseErr); err != nil {
+			return nil, fmt.Errorf("failed to write question: %w", err)
+		}
+	}
+}
+
+// FormatQuestion renders a question as text, with numbered choices and the default
+func FormatQuestion(question contracts.Question) string {
+	var text strings.Builder
+	text.WriteString("\n" + question.Text + "\n")
+	for i, choice := range question.Choices {
+		fmt.Fprintf(&text, "  %d) %s\n", i+1, choice)
+	}
+
+	var hints []string
+	switch {
+	case question.Confirm:
+		hints = append(hints, "yes/no")
+	case question.MultiSelect:
+		hints = append(hints, "numbers or choices, comma-separated")
+	case len(question.Choices) > 0:
+		hints = append(hints, "number or choice")
+	}
+	if question.Default != "" {
+		hints = append(hints, "default: "+question.Default)
+	}
+	if len(hints) > 0 {
+		fmt.Fprintf(&text, "[%s] ", strings.Join(hints, ", "))
+	}
+	text.WriteString("> ")
+	return text.String()
+}
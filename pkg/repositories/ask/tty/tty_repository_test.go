@@ -0,0 +1,39 @@
+package tty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+func TestPrompt(t *testing.T) {
+	question := contracts.Question{Text: "Which services?", Choices: []string{"api", "web", "worker"}, MultiSelect: true}
+
+	var out strings.Builder
+	answer, err := Prompt(question, strings.NewReader("api, cron\n1, 3\n"), &out)
+	if err != nil {
+		t.Fatalf("Failed to prompt: %v", err)
+	}
+	if strings.Join(answer.Selected, ",") != "api,worker" {
+		t.Errorf("Expected api and worker, got %+v", answer)
+	}
+	for _, expected := range []string{"  2) web", "comma-separated", "Invalid answer: 'cron' is not one of the choices"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	confirm := contracts.Question{Text: "Deploy?", Confirm: true, Default: "no"}
+	answer, err = Prompt(confirm, strings.NewReader("\n"), &out)
+	if err != nil {
+		t.Fatalf("Failed to prompt: %v", err)
+	}
+	if answer.Confirmed == nil || *answer.Confirmed {
+		t.Errorf("Expected the default 'no', got %+v", answer)
+	}
+
+	if _, err := Prompt(confirm, strings.NewReader("maybe"), &out); err == nil {
+		t.Error("Expected an error when the input ends without a valid answer")
+	}
+}
@@ -0,0 +1,260 @@
+package web
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// DefaultListenAddr is the address the web page is served on by default
+const DefaultListenAddr = "127.0.0.1:8090"
+
+// PollTimeout is how long a poll for questions waits for a change before answering unchanged
+const PollTimeout = 25 * time.Second
+
+//go:embed index.html
+var indexPage []byte
+
+// pendingQuestion is a question waiting for its answer on the web page
+type pendingQuestion struct {
+	ID string `json:"id"`
+	contracts.Question
+	AskedAt time.Time `json:"asked_at"`
+
+	result chan askResult
+}
+
+// askResult is the outcome of a pending question
+type askResult struct {
+	answer *contracts.Answer
+	err    error
+}
+
+// questionList is the answer to a poll, the pending questions with the version of the list
+type questionList struct {
+	Version   int                `json:"version"`
+	Questions []*pendingQuestion `json:"questions"`
+}
+
+// Repository asks questions on a local web page, for machines where dialogs don't work,
+// like Windows with WSL, remote SSH sessions or window managers without zenity.
+// The page long-polls for pending questions, so several questions can be open at once
+// and every question is answered as soon as the user submits it.
+type Repository struct {
+	pending []*pendingQuestion
+	nextID  int
+	version int
+	changed chan struct{}
+	mutex   sync.Mutex
+	mux     *http.ServeMux
+}
+
+// NewRepository creates a repository whose page is served by its ServeHTTP method
+func NewRepository() *Repository {
+	r := &Repository{changed: make(chan struct{})}
+	r.mux = http.NewServeMux()
+	r.mux.HandleFunc("GET /{$}", r.handleIndex)
+	r.mux.HandleFunc("GET /questions", r.handleQuestions)
+	r.mux.HandleFunc("POST /questions/{id}/answer", r.handleAnswer)
+	r.mux.HandleFunc("POST /questions/{id}/dismiss", r.handleDismiss)
+	return r
+}
+
+// Listen serves the page on addr in the background and returns the address it listens on
+func (r *Repository) Listen(addr string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	go func() {
+		if err := http.Serve(listener, r); err != nil {
+			log.Printf("Ask web page stopped: %v\n", err)
+		}
+	}()
+	return listener.Addr(), nil
+}
+
+// Ask shows the question on the page and waits until it is answered, dismissed or ctx is done
+func (r *Repository) Ask(ctx context.Context, question contracts.Question) (*contracts.Answer, error) {
+	r.mutex.Lock()
+	r.nextID++
+	pending := &pendingQuestion{
+		ID:       strconv.Itoa(r.nextID),
+		Question: question,
+		AskedAt:  time.Now(),
+		result:   make(chan askResult, 1),
+	}
+	r.pending = append(r.pending, pending)
+	r.notify()
+	r.mutex.Unlock()
+
+	select {
+	case result := <-pending.result:
+		return result.answer, result.err
+	case <-ctx.Done():
+		r.mutex.Lock()
+		r.take(pending.ID)
+		r.mutex.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// notify wakes up all polls waiting for a change. The mutex must be held.
+func (r *Repository) notify() {
+	r.version++
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// take removes a pending question and returns it, or nil if it isn't pending. The mutex must be held.
+func (r *Repository) take(id string) *pendingQuestion {
+	for i, pending := range r.pending {
+		if pending.ID == id {
+			r.pending = append(r.pending[:i], r.pending[i+1:]...)
+			r.notify()
+			return pending
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the page and its API to local browsers
+func (r *Repository) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Other websites open in the browser must not read or answer questions,
+	// neither directly nor through a DNS name rebound to this machine
+	if !isLocalHost(req.Host) {
+		http.Error(w, "only local requests are allowed", http.StatusForbidden)
+		return
+	}
+	if req.Method != http.MethodGet && !isSameOrigin(req) {
+		http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+		return
+	}
+	r.mux.ServeHTTP(w, req)
+}
+
+// handleIndex serves the page
+func (r *Repository) handleIndex(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(indexPage)
+}
+
+// handleQuestions returns the pending questions. With the version of the last
+// list it waits until the list changes, the poll times out or the page goes away.
+func (r *Repository) handleQuestions(w http.ResponseWriter, req *http.Request) {
+	since, err := strconv.Atoi(req.URL.Query().Get("version"))
+	if err != nil {
+		since = -1
+	}
+
+	r.mutex.Lock()
+	if since == r.version {
+		changed := r.changed
+		r.mutex.Unlock()
+		select {
+		case <-changed:
+		case <-time.After(PollTimeout):
+		case <-req.Context().Done():
+			return
+		}
+		r.mutex.Lock()
+	}
+	list := questionList{Version: r.version, Questions: append([]*pendingQuestion{}, r.pending...)}
+	r.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleAnswer answers a pending question with the submitted form
+func (r *Repository) handleAnswer(w http.ResponseWriter, req *http.Request) {
+	var form contracts.FormAnswer
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, 1<<20)).Decode(&form); err != nil {
+		http.Error(w, "invalid answer JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pending := r.find(req.PathValue("id"))
+	if pending == nil {
+		http.Error(w, "question is no longer pending", http.StatusNotFound)
+		return
+	}
+	answer, err := contracts.ParseFormAnswer(pending.Question, form)
+	if err != nil {
+		// The question stays open, so the user can correct the answer
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.take(pending.ID)
+	pending.result <- askResult{answer: answer}
+	writeJSON(w, http.StatusOK, answer)
+}
+
+// handleDismiss closes a pending question without answering it
+func (r *Repository) handleDismiss(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pending := r.take(req.PathValue("id"))
+	if pending == nil {
+		http.Error(w, "question is no longer pending", http.StatusNotFound)
+		return
+	}
+	pending.result <- askResult{err: contracts.ErrQuestionDismissed}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// find returns a pending question, or nil if it isn't pending. The mutex must be held.
+func (r *Repository) find(id string) *pendingQuestion {
+	for _, pending := range r.pending {
+		if pending.ID == id {
+			return pending
+		}
+	}
+	return nil
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// isLocalHost reports whether the Host header of a request names the local machine
+func isLocalHost(host string) bool {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isSameOrigin reports whether a request was sent by the page itself.
+// Browsers send the Origin header with every cross-origin POST.
+func isSameOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && parsed.Host == req.Host
+}
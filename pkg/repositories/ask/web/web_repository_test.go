@@ -0,0 +1,211 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// askResultOf asks a question in the background and delivers the outcome
+func askResultOf(ctx context.Context, repo *Repository, question contracts.Question) <-chan askResult {
+	results := make(chan askResult, 1)
+	go func() {
+		answer, err := repo.Ask(ctx, question)
+		results <- askResult{answer: answer, err: err}
+	}()
+	return results
+}
+
+// fetchQuestions fetches the pending questions, waiting for a change of the given version
+func fetchQuestions(server *httptest.Server, version int) (questionList, error) {
+	var list questionList
+	response, err := http.Get(server.URL + "/questions?version=" + strconv.Itoa(version))
+	if err != nil {
+		return list, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	err = json.NewDecoder(response.Body).Decode(&list)
+	return list, err
+}
+
+// poll fetches the pending questions, failing the test on errors
+func poll(t *testing.T, server *httptest.Server, version int) questionList {
+	list, err := fetchQuestions(server, version)
+	if err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	return list
+}
+
+// waitForQuestions polls until the given number of questions is pending
+func waitForQuestions(t *testing.T, server *httptest.Server, count int) questionList {
+	list := poll(t, server, -1)
+	for len(list.Questions) != count {
+		list = poll(t, server, list.Version)
+	}
+	return list
+}
+
+// post sends a form to the API and returns the status code and body
+func post(t *testing.T, server *httptest.Server, path string, body string) (int, string) {
+	response, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	data, _ := io.ReadAll(response.Body)
+	return response.StatusCode, string(data)
+}
+
+// receive waits for the outcome of a question
+func receive(t *testing.T, results <-chan askResult) askResult {
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the question to resolve")
+		return askResult{}
+	}
+}
+
+func TestRepository(t *testing.T) {
+	repo := NewRepository()
+	server := httptest.NewServer(repo)
+	defer server.Close()
+
+	t.Run("the page is served", func(t *testing.T) {
+		response, err := http.Get(server.URL + "/")
+		if err != nil {
+			t.Fatalf("Failed to get page: %v", err)
+		}
+		defer func() { _ = response.Body.Close() }()
+		page, _ := io.ReadAll(response.Body)
+		if response.StatusCode != http.StatusOK || !strings.Contains(string(page), "questions?version=") {
+			t.Errorf("Expected the page, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("polls wait for new questions", func(t *testing.T) {
+		version := poll(t, server, -1).Version
+		polled := make(chan questionList, 1)
+		go func() {
+			list, _ := fetchQuestions(server, version)
+			polled <- list
+		}()
+
+		select {
+		case list := <-polled:
+			t.Fatalf("Expected the poll to wait, got %+v", list)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		results := askResultOf(context.Background(), repo, contracts.Question{Text: "Deploy now?", Confirm: true})
+		list := <-polled
+		if len(list.Questions) != 1 || list.Questions[0].Text != "Deploy now?" || !list.Questions[0].Confirm {
+			t.Fatalf("Expected the new question, got %+v", list)
+		}
+
+		if status, body := post(t, server, "/questions/"+list.Questions[0].ID+"/answer", `{"confirmed": true}`); status != http.StatusOK {
+			t.Fatalf("Failed to answer: %d %s", status, body)
+		}
+		result := receive(t, results)
+		if result.err != nil || result.answer.Confirmed == nil || !*result.answer.Confirmed {
+			t.Errorf("Expected a confirmation, got %+v", result)
+		}
+	})
+
+	t.Run("concurrent questions are answered independently", func(t *testing.T) {
+		database := askResultOf(context.Background(), repo, contracts.Question{Text: "Which database?", Choices: []string{"SQLite", "Postgres"}})
+		services := askResultOf(context.Background(), repo, contracts.Question{Text: "Which services?", Choices: []string{"api", "web"}, MultiSelect: true})
+		list := waitForQuestions(t, server, 2)
+
+		ids := map[string]string{}
+		for _, question := range list.Questions {
+			ids[question.Text] = question.ID
+		}
+		if status, _ := post(t, server, "/questions/"+ids["Which services?"]+"/answer", `{"selected": ["api", "web"]}`); status != http.StatusOK {
+			t.Fatalf("Failed to answer services, got %d", status)
+		}
+		result := receive(t, services)
+		if result.err != nil || len(result.answer.Selected) != 2 {
+			t.Errorf("Expected both services, got %+v", result)
+		}
+
+		if status, _ := post(t, server, "/questions/"+ids["Which database?"]+"/answer", `{"answer": "MySQL"}`); status != http.StatusBadRequest {
+			t.Errorf("Expected an unknown choice to be rejected, got %d", status)
+		}
+		if status, _ := post(t, server, "/questions/"+ids["Which database?"]+"/answer", `{"answer": "Postgres"}`); status != http.StatusOK {
+			t.Fatalf("Failed to answer database, got %d", status)
+		}
+		result = receive(t, database)
+		if result.err != nil || result.answer.Text != "Postgres" {
+			t.Errorf("Expected Postgres, got %+v", result)
+		}
+
+		if status, _ := post(t, server, "/questions/"+ids["Which database?"]+"/answer", `{"answer": "SQLite"}`); status != http.StatusNotFound {
+			t.Errorf("Expected answered questions to be gone, got %d", status)
+		}
+	})
+
+	t.Run("dismissed questions", func(t *testing.T) {
+		results := askResultOf(context.Background(), repo, contracts.Question{Text: "Name?"})
+		list := waitForQuestions(t, server, 1)
+		if status, _ := post(t, server, "/questions/"+list.Questions[0].ID+"/dismiss", ""); status != http.StatusNoContent {
+			t.Fatalf("Failed to dismiss, got %d", status)
+		}
+		if result := receive(t, results); !errors.Is(result.err, contracts.ErrQuestionDismissed) {
+			t.Errorf("Expected the question to be dismissed, got %+v", result)
+		}
+	})
+
+	t.Run("cancelled questions disappear from the page", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		results := askResultOf(ctx, repo, contracts.Question{Text: "Name?"})
+		waitForQuestions(t, server, 1)
+		cancel()
+		if result := receive(t, results); !errors.Is(result.err, context.Canceled) {
+			t.Errorf("Expected the question to be cancelled, got %+v", result)
+		}
+		waitForQuestions(t, server, 0)
+	})
+
+	t.Run("other websites can't answer", func(t *testing.T) {
+		results := askResultOf(context.Background(), repo, contracts.Question{Text: "Deploy now?", Confirm: true})
+		list := waitForQuestions(t, server, 1)
+
+		request, _ := http.NewRequest(http.MethodPost, server.URL+"/questions/"+list.Questions[0].ID+"/answer", strings.NewReader(`{"confirmed": true}`))
+		request.Header.Set("Origin", "https://evil.example")
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Failed to post: %v", err)
+		}
+		_ = response.Body.Close()
+		if response.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected cross-origin answers to be rejected, got %d", response.StatusCode)
+		}
+
+		request, _ = http.NewRequest(http.MethodGet, server.URL+"/questions", nil)
+		request.Host = "rebound.example:80"
+		response, err = http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Failed to get: %v", err)
+		}
+		_ = response.Body.Close()
+		if response.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected foreign host names to be rejected, got %d", response.StatusCode)
+		}
+
+		post(t, server, "/questions/"+list.Questions[0].ID+"/dismiss", "")
+		receive(t, results)
+	})
+}
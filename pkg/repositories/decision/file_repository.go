@@ -0,0 +1,226 @@
+package decision
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+	"github.com/mstrehse/mcp-brain/pkg/internal/textsearch"
+)
+
+// defaultSearchLimit is the number of results returned when the query has no limit
+const defaultSearchLimit = 10
+
+// FileRepository keeps the decisions as an append-only log with one JSON object per line
+type FileRepository struct {
+	logPath string
+	lastID  int
+	mutex   sync.Mutex
+}
+
+// NewFileRepository creates a decision log in <baseDir>/decisions
+func NewFileRepository(baseDir string) (*FileRepository, error) {
+	dir := filepath.Join(baseDir, "decisions")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create decisions directory: %w", err)
+	}
+
+	repo := &FileRepository{logPath: filepath.Join(dir, "decisions.jsonl")}
+	decisions, err := repo.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, decision := range decisions {
+		var id int
+		if _, err := fmt.Sscanf(decision.ID, "decision-%d", &id); err == nil && id > repo.lastID {
+			repo.lastID = id
+		}
+	}
+	return repo, nil
+}
+
+// Close is a no-op for file-based storage
+func (r *FileRepository) Close() error {
+	return nil
+}
+
+// load reads all decisions of the log in the order they were made
+func (r *FileRepository) load() ([]*contracts.Decision, error) {
+	file, err := os.Open(r.logPath)
+	if os.IsNotExist(err) {
+		return []*contracts.Decision{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open decision log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	decisions := []*contracts.Decision{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var decision contracts.Decision
+		if err := json.Unmarshal([]byte(line), &decision); err != nil || decision.Answer == nil {
+			// A line cut off by a crash must not make the whole log unreadable
+			continue
+		}
+		decisions = append(decisions, &decision)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read decision log: %w", err)
+	}
+	return decisions, nil
+}
+
+// Record appends a decision to the log
+func (r *FileRepository) Record(decision *contracts.Decision) (*contracts.Decision, error) {
+	if decision.Answer == nil {
+		return nil, fmt.Errorf("decision has no answer")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	recorded := *decision
+	r.lastID++
+	recorded.ID = fmt.Sprintf("decision-%d", r.lastID)
+	if recorded.AskedAt.IsZero() {
+		recorded.AskedAt = time.Now()
+	}
+
+	data, err := json.Marshal(&recorded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal decision: %w", err)
+	}
+
+	file, err := os.OpenFile(r.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open decision log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write decision: %w", err)
+	}
+
+	return &recorded, nil
+}
+
+// Search ranks the decisions by the share of query words found in their question, choices and answer.
+// Equally good matches are ordered newest first.
+func (r *FileRepository) Search(query contracts.DecisionQuery) ([]*contracts.DecisionResult, error) {
+	terms := textsearch.UniqueTerms(textsearch.Tokenize(query.Query))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query must contain at least one word")
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	r.mutex.Lock()
+	decisions, err := r.load()
+	r.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*contracts.DecisionResult{}
+	for _, decision := range decisions {
+		if query.Session != "" && decision.Session != query.Session {
+			continue
+		}
+
+		words := make(map[string]bool)
+		for _, word := range textsearch.Tokenize(decisionText(decision)) {
+			words[word] = true
+		}
+		matched := 0
+		for _, term := range terms {
+			if words[term] {
+				matched++
+			}
+		}
+		if matched > 0 {
+			results = append(results, &contracts.DecisionResult{
+				Decision: decision,
+				Score:    math.Round(float64(matched)/float64(len(terms))*1000) / 1000,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].AskedAt.After(results[j].AskedAt)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Find returns the latest decision for the same question
+func (r *FileRepository) Find(question contracts.Question) (*contracts.Decision, error) {
+	r.mutex.Lock()
+	decisions, err := r.load()
+	r.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(decisions) - 1; i >= 0; i-- {
+		decision := decisions[i]
+		if !decision.Answer.TimedOut && sameQuestion(decision.Question, question) {
+			return decision, nil
+		}
+	}
+	return nil, nil
+}
+
+// sameQuestion reports whether two questions ask the same, ignoring case, punctuation and the order of choices
+func sameQuestion(a contracts.Question, b contracts.Question) bool {
+	if a.Confirm != b.Confirm || a.MultiSelect != b.MultiSelect || len(a.Choices) != len(b.Choices) {
+		return false
+	}
+	if strings.Join(textsearch.Tokenize(a.Text), " ") != strings.Join(textsearch.Tokenize(b.Text), " ") {
+		return false
+	}
+	choices := make(map[string]bool, len(a.Choices))
+	for _, choice := range a.Choices {
+		choices[choice] = true
+	}
+	for _, choice := range b.Choices {
+		if !choices[choice] {
+			return false
+		}
+	}
+	return true
+}
+
+// decisionText returns the searchable text of a decision
+func decisionText(decision *contracts.Decision) string {
+	parts := append([]string{decision.Text}, decision.Choices...)
+	parts = append(parts, decision.Answer.Text)
+	parts = append(parts, decision.Answer.Selected...)
+	if decision.Answer.Confirmed != nil {
+		if *decision.Answer.Confirmed {
+			parts = append(parts, "yes")
+		} else {
+			parts = append(parts, "no")
+		}
+	}
+	return strings.Join(parts, "\n")
+}
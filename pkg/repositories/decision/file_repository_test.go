@@ -0,0 +1,109 @@
+package decision
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+func TestFileRepository(t *testing.T) {
+	baseDir := t.TempDir()
+	repo, err := NewFileRepository(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	database := contracts.Question{Text: "Which database should we use?", Choices: []string{"SQLite", "Postgres"}}
+	start := time.Now().Add(-time.Hour)
+	for i, decision := range []*contracts.Decision{
+		{Question: database, Answer: &contracts.Answer{Text: "SQLite", Selected: []string{"SQLite"}}, Session: "a", AskedAt: start},
+		{Question: contracts.Question{Text: "Deploy to staging?", Confirm: true}, Answer: contracts.NewConfirmAnswer(true), Session: "a", AskedAt: start.Add(time.Minute)},
+		{Question: database, Answer: &contracts.Answer{Text: "Postgres", Selected: []string{"Postgres"}}, Session: "b", AskedAt: start.Add(2 * time.Minute)},
+	} {
+		recorded, err := repo.Record(decision)
+		if err != nil {
+			t.Fatalf("Failed to record decision: %v", err)
+		}
+		if expected := "decision-" + string(rune('1'+i)); recorded.ID != expected {
+			t.Errorf("Expected ID %s, got %s", expected, recorded.ID)
+		}
+	}
+
+	t.Run("search ranks by matched words and recency", func(t *testing.T) {
+		results, err := repo.Search(contracts.DecisionQuery{Query: "deploy staging database"})
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(results) != 3 || !results[0].Confirm || results[0].Score != 0.667 || results[1].Answer.Text != "Postgres" || results[2].Answer.Text != "SQLite" {
+			t.Errorf("Expected the deploy decision first and newer decisions before older ones, got %+v", results)
+		}
+
+		results, err = repo.Search(contracts.DecisionQuery{Query: "database", Session: "a"})
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(results) != 1 || results[0].Answer.Text != "SQLite" {
+			t.Errorf("Expected only the decision of session a, got %+v", results)
+		}
+
+		if _, err := repo.Search(contracts.DecisionQuery{Query: "?!"}); err == nil {
+			t.Error("Expected a query without words to be rejected")
+		}
+	})
+
+	t.Run("find returns the latest answer to the same question", func(t *testing.T) {
+		reordered := contracts.Question{Text: "which database should we use", Choices: []string{"Postgres", "SQLite"}}
+		decision, err := repo.Find(reordered)
+		if err != nil {
+			t.Fatalf("Failed to find decision: %v", err)
+		}
+		if decision == nil || decision.Answer.Text != "Postgres" {
+			t.Errorf("Expected the latest answer, got %+v", decision)
+		}
+
+		for name, question := range map[string]contracts.Question{
+			"other choices": {Text: database.Text, Choices: []string{"SQLite", "MySQL"}},
+			"other kind":    {Text: "Deploy to staging?"},
+		} {
+			if decision, err := repo.Find(question); err != nil || decision != nil {
+				t.Errorf("%s: expected no decision, got %+v (%v)", name, decision, err)
+			}
+		}
+	})
+
+	t.Run("timed out answers are not reused", func(t *testing.T) {
+		question := contracts.Question{Text: "Run migrations?", Confirm: true, Default: "no"}
+		if _, err := repo.Record(&contracts.Decision{Question: question, Answer: question.DefaultAnswer()}); err != nil {
+			t.Fatalf("Failed to record decision: %v", err)
+		}
+		if decision, err := repo.Find(question); err != nil || decision != nil {
+			t.Errorf("Expected no reusable decision, got %+v (%v)", decision, err)
+		}
+	})
+
+	t.Run("the log survives restarts and broken lines", func(t *testing.T) {
+		logPath := filepath.Join(baseDir, "decisions", "decisions.jsonl")
+		file, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open log: %v", err)
+		}
+		_, _ = file.WriteString(`{"id": "decision-9", "question": "cut of`)
+		_ = file.Close()
+
+		reopened, err := NewFileRepository(baseDir)
+		if err != nil {
+			t.Fatalf("Failed to reopen repository: %v", err)
+		}
+		results, err := reopened.Search(contracts.DecisionQuery{Query: "database"})
+		if err != nil || len(results) != 2 {
+			t.Fatalf("Expected the earlier decisions, got %+v (%v)", results, err)
+		}
+		recorded, err := reopened.Record(&contracts.Decision{Question: database, Answer: &contracts.Answer{Text: "SQLite"}})
+		if err != nil || recorded.ID != "decision-5" {
+			t.Errorf("Expected IDs to continue after the last decision, got %+v (%v)", recorded, err)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package embedding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+
+	"github.com/mstrehse/mcp-brain/pkg/internal/textsearch"
+)
+
+// DefaultDimensions is the vector size used by NewHashingProvider
+const DefaultDimensions = 512
+
+// HashingProvider creates embeddings locally by hashing words and character
+// n-grams into a fixed number of dimensions. It needs no model or network
+// access, and the character n-grams let related word forms like "authenticate"
+// and "authentication" end up close to each other.
+type HashingProvider struct {
+	dimensions int
+}
+
+// NewHashingProvider creates a hashing provider with the default number of dimensions
+func NewHashingProvider() *HashingProvider {
+	return &HashingProvider{
+		dimensions: DefaultDimensions,
+	}
+}
+
+// Name identifies the provider and its configuration
+func (p *HashingProvider) Name() string {
+	return fmt.Sprintf("hashing-ngram-%d", p.dimensions)
+}
+
+// Embed returns one normalized vector per text
+func (p *HashingProvider) Embed(texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = p.embed(text)
+	}
+	return vectors, nil
+}
+
+// embed hashes the features of a text into a normalized vector
+func (p *HashingProvider) embed(text string) []float32 {
+	counts := make(map[string]int)
+	for _, word := range textsearch.Tokenize(text) {
+		counts["w:"+word]++
+
+		// Character trigrams of the padded word
+		padded := []rune("<" + word + ">")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts["c:"+string(padded[i:i+3])]++
+		}
+	}
+
+	vector := make([]float64, p.dimensions)
+	for feature, count := range counts {
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte(feature))
+		sum := hash.Sum64()
+
+		// The lowest bit decides the sign, so that collisions tend to cancel out
+		weight := 1 + math.Log(float64(count))
+		if sum&1 == 1 {
+			weight = -weight
+		}
+		vector[(sum>>1)%uint64(p.dimensions)] += weight
+	}
+
+	norm := 0.0
+	for _, value := range vector {
+		norm += value * value
+	}
+	norm = math.Sqrt(norm)
+
+	result := make([]float32, p.dimensions)
+	if norm == 0 {
+		return result
+	}
+	for i, value := range vector {
+		result[i] = float32(value / norm)
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package embedding
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHashingProvider(t *testing.T) {
+	provider := NewHashingProvider()
+
+	vectors, err := provider.Embed([]string{
+		"How the authentication flow works",
+		"The authenticated user flow",
+		"Recipe for apple pie",
+		"",
+	})
+	if err != nil {
+		t.Fatalf("Failed to embed: %v", err)
+	}
+	if len(vectors) != 4 {
+		t.Fatalf("Expected 4 vectors, got %d", len(vectors))
+	}
+
+	for i, vector := range vectors[:3] {
+		if len(vector) != DefaultDimensions {
+			t.Errorf("Expected %d dimensions, got %d", DefaultDimensions, len(vector))
+		}
+		norm := 0.0
+		for _, value := range vector {
+			norm += float64(value) * float64(value)
+		}
+		if math.Abs(norm-1) > 1e-4 {
+			t.Errorf("Expected vector %d to be normalized, got norm %f", i, norm)
+		}
+	}
+
+	related := Cosine(vectors[0], vectors[1])
+	unrelated := Cosine(vectors[0], vectors[2])
+	if related <= unrelated {
+		t.Errorf("Expected related texts to be more similar (%f) than unrelated ones (%f)", related, unrelated)
+	}
+
+	// Embeddings are deterministic
+	again, _ := provider.Embed([]string{"How the authentication flow works"})
+	if Cosine(vectors[0], again[0]) < 0.9999 {
+		t.Error("Expected identical texts to get identical vectors")
+	}
+
+	// Empty texts can't be compared
+	if Cosine(vectors[0], vectors[3]) != 0 {
+		t.Error("Expected empty text to have no similarity")
+	}
+}
+
+func TestCosine(t *testing.T) {
+	if got := Cosine([]float32{1, 0}, []float32{2, 0}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Expected 1, got %f", got)
+	}
+	if got := Cosine([]float32{1, 0}, []float32{0, 1}); got != 0 {
+		t.Errorf("Expected 0, got %f", got)
+	}
+	if got := Cosine([]float32{1, 0}, []float32{1, 0, 0}); got != 0 {
+		t.Errorf("Expected 0 for different dimensions, got %f", got)
+	}
+}
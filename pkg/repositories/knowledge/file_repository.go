@@ -5,17 +5,35 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/mstrehse/mcp-brain/pkg/contracts"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/embedding"
 )
 
-// FileRepository handles file-based storage for knowledge using markdown files
+// layoutMarker is created inside the knowledge directory once it uses the per-project layout
+const layoutMarker = ".projects"
+
+// FileRepository handles file-based storage for knowledge using markdown files.
+// Every project gets its own subdirectory below the knowledge directory.
 type FileRepository struct {
-	baseDir string
+	baseDir    string
+	searchDir  string
+	vectorDir  string
+	embeddings contracts.EmbeddingProvider
+	mutex      sync.Mutex
 }
 
-// NewFileRepository creates a new file-based repository
+// NewFileRepository creates a new file-based repository that finds related
+// knowledge with local hashed n-gram embeddings
 func NewFileRepository(baseDir string) (*FileRepository, error) {
+	return NewFileRepositoryWithEmbeddings(baseDir, embedding.NewHashingProvider())
+}
+
+// NewFileRepositoryWithEmbeddings creates a new file-based repository that finds
+// related knowledge with the given embedding provider
+func NewFileRepositoryWithEmbeddings(baseDir string, embeddings contracts.EmbeddingProvider) (*FileRepository, error) {
 	knowledgeDir := filepath.Join(baseDir, "knowledge")
 
 	// Ensure the knowledge directory exists
@@ -23,9 +41,18 @@ func NewFileRepository(baseDir string) (*FileRepository, error) {
 		return nil, fmt.Errorf("failed to create knowledge directory: %w", err)
 	}
 
-	return &FileRepository{
-		baseDir: knowledgeDir,
-	}, nil
+	repo := &FileRepository{
+		baseDir:    knowledgeDir,
+		searchDir:  filepath.Join(baseDir, "index", "search"),
+		vectorDir:  filepath.Join(baseDir, "index", "vectors"),
+		embeddings: embeddings,
+	}
+
+	if err := repo.migrateFlatLayout(); err != nil {
+		return nil, fmt.Errorf("failed to migrate knowledge directory: %w", err)
+	}
+
+	return repo, nil
 }
 
 // Close is a no-op for file-based storage
@@ -33,22 +60,109 @@ func (r *FileRepository) Close() error {
 	return nil
 }
 
-// List returns a json representation of the directory and file structure
-func (r *FileRepository) List() (contracts.DirStructure, error) {
+// migrateFlatLayout moves knowledge stored before projects existed into the default project
+func (r *FileRepository) migrateFlatLayout() error {
+	markerPath := filepath.Join(r.baseDir, layoutMarker)
+	if _, err := os.Stat(markerPath); err == nil {
+		return nil
+	}
+
+	entries, err := os.ReadDir(r.baseDir)
+	if err != nil {
+		return fmt.Errorf("failed to read knowledge directory: %w", err)
+	}
+
+	var legacy []os.DirEntry
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), ".") {
+			legacy = append(legacy, entry)
+		}
+	}
+
+	if len(legacy) > 0 {
+		// Move everything into a staging directory first, so that a legacy
+		// folder called like the default project does not get in the way
+		stagingDir := filepath.Join(r.baseDir, fmt.Sprintf(".migrate-%d", time.Now().UnixNano()))
+		if err := os.Mkdir(stagingDir, 0755); err != nil {
+			return fmt.Errorf("failed to create staging directory: %w", err)
+		}
+
+		for _, entry := range legacy {
+			if err := os.Rename(filepath.Join(r.baseDir, entry.Name()), filepath.Join(stagingDir, entry.Name())); err != nil {
+				return fmt.Errorf("failed to move %s: %w", entry.Name(), err)
+			}
+		}
+
+		if err := os.Rename(stagingDir, filepath.Join(r.baseDir, contracts.DefaultProject)); err != nil {
+			return fmt.Errorf("failed to create default project: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(markerPath, []byte{}, 0644); err != nil {
+		return fmt.Errorf("failed to write layout marker: %w", err)
+	}
+
+	return nil
+}
+
+// projectDir returns the directory holding the knowledge of a project
+func (r *FileRepository) projectDir(project string) (string, error) {
+	return resolveProjectDir(r.baseDir, project)
+}
+
+// filePath returns the markdown file of a project a knowledge path refers to
+func (r *FileRepository) filePath(project string, path string) (string, error) {
+	projectDir, err := r.projectDir(project)
+	if err != nil {
+		return "", err
+	}
+
+	return resolvePath(projectDir, path)
+}
+
+// ListProjects returns the names of all projects that contain knowledge
+func (r *FileRepository) ListProjects() ([]string, error) {
+	entries, err := os.ReadDir(r.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read knowledge directory: %w", err)
+	}
+
+	projects := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			projects = append(projects, entry.Name())
+		}
+	}
+
+	return projects, nil
+}
+
+// List returns a json representation of the directory and file structure of a project
+func (r *FileRepository) List(project string) (contracts.DirStructure, error) {
+	projectDir, err := r.projectDir(project)
+	if err != nil {
+		return nil, err
+	}
+
 	result := contracts.DirStructure{}
 
-	err := filepath.Walk(r.baseDir, func(path string, info os.FileInfo, err error) error {
+	// A project without any knowledge yet has an empty structure
+	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
+		return result, nil
+	}
+
+	err = filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Skip the base directory itself
-		if path == r.baseDir {
+		// Skip the project directory itself
+		if path == projectDir {
 			return nil
 		}
 
-		// Get relative path from base directory
-		relPath, err := filepath.Rel(r.baseDir, path)
+		// Get relative path from project directory
+		relPath, err := filepath.Rel(projectDir, path)
 		if err != nil {
 			return err
 		}
@@ -93,16 +207,13 @@ func (r *FileRepository) insertPathIntoStructure(structure contracts.DirStructur
 	}
 }
 
-// Write knowledge to the filesystem
-func (r *FileRepository) Write(path string, content string) error {
-	// Ensure the path uses forward slashes and add .md extension if not present
-	normalizedPath := filepath.ToSlash(path)
-	if !strings.HasSuffix(normalizedPath, ".md") {
-		normalizedPath += ".md"
+// Write knowledge of a project to the filesystem
+func (r *FileRepository) Write(project string, path string, content string) error {
+	fullPath, err := r.filePath(project, path)
+	if err != nil {
+		return err
 	}
 
-	fullPath := filepath.Join(r.baseDir, normalizedPath)
-
 	// Ensure parent directories exist
 	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
 		return fmt.Errorf("failed to create parent directories: %w", err)
@@ -113,19 +224,16 @@ func (r *FileRepository) Write(path string, content string) error {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return nil
+	return r.updateSearchIndex(project, fullPath)
 }
 
-// Read knowledge from the filesystem
-func (r *FileRepository) Read(path string) (string, error) {
-	// Normalize path and add .md extension if not present
-	normalizedPath := filepath.ToSlash(path)
-	if !strings.HasSuffix(normalizedPath, ".md") {
-		normalizedPath += ".md"
+// Read knowledge of a project from the filesystem
+func (r *FileRepository) Read(project string, path string) (string, error) {
+	fullPath, err := r.filePath(project, path)
+	if err != nil {
+		return "", err
 	}
 
-	fullPath := filepath.Join(r.baseDir, normalizedPath)
-
 	content, err := os.ReadFile(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -137,16 +245,13 @@ func (r *FileRepository) Read(path string) (string, error) {
 	return string(content), nil
 }
 
-// Delete knowledge from the filesystem
-func (r *FileRepository) Delete(path string) error {
-	// Normalize path and add .md extension if not present
-	normalizedPath := filepath.ToSlash(path)
-	if !strings.HasSuffix(normalizedPath, ".md") {
-		normalizedPath += ".md"
+// Delete knowledge of a project from the filesystem
+func (r *FileRepository) Delete(project string, path string) error {
+	fullPath, err := r.filePath(project, path)
+	if err != nil {
+		return err
 	}
 
-	fullPath := filepath.Join(r.baseDir, normalizedPath)
-
 	if err := os.Remove(fullPath); err != nil {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("knowledge file not found: %s", path)
@@ -154,10 +259,10 @@ func (r *FileRepository) Delete(path string) error {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
 
-	// Try to remove empty parent directories
+	// Try to remove empty parent directories, including an empty project directory
 	r.removeEmptyParentDirs(filepath.Dir(fullPath))
 
-	return nil
+	return r.updateSearchIndex(project, fullPath)
 }
 
 // removeEmptyParentDirs removes empty parent directories up to but not including the base directory
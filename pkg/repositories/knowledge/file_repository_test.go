@@ -23,12 +23,12 @@ func TestFileRepository(t *testing.T) {
 
 	// Test Write operation
 	testContent := "# Test Knowledge\n\nThis is a test knowledge file."
-	if err := repo.Write("test/knowledge", testContent); err != nil {
+	if err := repo.Write("test-project", "test/knowledge", testContent); err != nil {
 		t.Fatalf("Failed to write knowledge: %v", err)
 	}
 
 	// Test Read operation
-	readContent, err := repo.Read("test/knowledge")
+	readContent, err := repo.Read("test-project", "test/knowledge")
 	if err != nil {
 		t.Fatalf("Failed to read knowledge: %v", err)
 	}
@@ -37,7 +37,7 @@ func TestFileRepository(t *testing.T) {
 	}
 
 	// Test List operation
-	structure, err := repo.List()
+	structure, err := repo.List("test-project")
 	if err != nil {
 		t.Fatalf("Failed to list knowledge: %v", err)
 	}
@@ -61,12 +61,12 @@ func TestFileRepository(t *testing.T) {
 	}
 
 	// Test Delete operation
-	if err := repo.Delete("test/knowledge"); err != nil {
+	if err := repo.Delete("test-project", "test/knowledge"); err != nil {
 		t.Fatalf("Failed to delete knowledge: %v", err)
 	}
 
 	// Verify file is deleted
-	_, err = repo.Read("test/knowledge")
+	_, err = repo.Read("test-project", "test/knowledge")
 	if err == nil {
 		t.Fatal("Expected error when reading deleted file")
 	}
@@ -89,18 +89,18 @@ func TestFileRepositoryPathNormalization(t *testing.T) {
 
 	// Test writing without .md extension
 	testContent := "# Test Knowledge\n\nThis is a test knowledge file."
-	if err := repo.Write("test", testContent); err != nil {
+	if err := repo.Write("test-project", "test", testContent); err != nil {
 		t.Fatalf("Failed to write knowledge: %v", err)
 	}
 
 	// Verify file was created with .md extension
-	fullPath := filepath.Join(repo.baseDir, "test.md")
+	fullPath := filepath.Join(repo.baseDir, "test-project", "test.md")
 	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
 		t.Fatal("File was not created with .md extension")
 	}
 
 	// Test reading without .md extension
-	readContent, err := repo.Read("test")
+	readContent, err := repo.Read("test-project", "test")
 	if err != nil {
 		t.Fatalf("Failed to read knowledge: %v", err)
 	}
@@ -126,18 +126,18 @@ func TestFileRepositoryEmptyDirCleanup(t *testing.T) {
 
 	// Create a file in a nested directory
 	testContent := "# Test Knowledge"
-	if err := repo.Write("deep/nested/test", testContent); err != nil {
+	if err := repo.Write("test-project", "deep/nested/test", testContent); err != nil {
 		t.Fatalf("Failed to write knowledge: %v", err)
 	}
 
 	// Verify nested directory structure was created
-	deepDir := filepath.Join(repo.baseDir, "deep", "nested")
+	deepDir := filepath.Join(repo.baseDir, "test-project", "deep", "nested")
 	if _, err := os.Stat(deepDir); os.IsNotExist(err) {
 		t.Fatal("Nested directory was not created")
 	}
 
 	// Delete the file
-	if err := repo.Delete("deep/nested/test"); err != nil {
+	if err := repo.Delete("test-project", "deep/nested/test"); err != nil {
 		t.Fatalf("Failed to delete knowledge: %v", err)
 	}
 
@@ -148,3 +148,139 @@ func TestFileRepositoryEmptyDirCleanup(t *testing.T) {
 		t.Logf("Empty parent directory still exists: %s", deepDir)
 	}
 }
+
+func TestFileRepositoryProjectIsolation(t *testing.T) {
+	// Create a temporary directory for testing
+	tempDir, err := os.MkdirTemp("", "test_knowledge_repo")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	// Create repository
+	repo, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	if err := repo.Write("project-a", "notes", "content a"); err != nil {
+		t.Fatalf("Failed to write knowledge: %v", err)
+	}
+	if err := repo.Write("project-b", "notes", "content b"); err != nil {
+		t.Fatalf("Failed to write knowledge: %v", err)
+	}
+
+	// Same path in different projects must not collide
+	content, err := repo.Read("project-a", "notes")
+	if err != nil {
+		t.Fatalf("Failed to read knowledge: %v", err)
+	}
+	if content != "content a" {
+		t.Errorf("Expected 'content a', got %q", content)
+	}
+
+	// Knowledge of one project must not be visible in another
+	if _, err := repo.Read("project-c", "notes"); err == nil {
+		t.Error("Expected error when reading knowledge of another project")
+	}
+
+	structure, err := repo.List("project-c")
+	if err != nil {
+		t.Fatalf("Failed to list knowledge: %v", err)
+	}
+	if len(structure) != 0 {
+		t.Errorf("Expected empty structure for unknown project, got %v", structure)
+	}
+
+	projects, err := repo.ListProjects()
+	if err != nil {
+		t.Fatalf("Failed to list projects: %v", err)
+	}
+	if len(projects) != 2 || projects[0] != "project-a" || projects[1] != "project-b" {
+		t.Errorf("Expected [project-a project-b], got %v", projects)
+	}
+
+	// Deleting the last file of a project removes the project
+	if err := repo.Delete("project-b", "notes"); err != nil {
+		t.Fatalf("Failed to delete knowledge: %v", err)
+	}
+	projects, err = repo.ListProjects()
+	if err != nil {
+		t.Fatalf("Failed to list projects: %v", err)
+	}
+	if len(projects) != 1 || projects[0] != "project-a" {
+		t.Errorf("Expected [project-a], got %v", projects)
+	}
+}
+
+func TestFileRepositoryInvalidProject(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	for _, project := range []string{"", "a/b", `a\b`, "..", ".hidden"} {
+		if err := repo.Write(project, "notes", "content"); err == nil {
+			t.Errorf("Expected error for project name %q", project)
+		}
+	}
+}
+
+func TestFileRepositoryMigratesFlatLayout(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Simulate a brain created before projects existed, including a
+	// legacy folder that has the same name as the default project
+	legacyFiles := map[string]string{
+		"root.md":           "root content",
+		"nested/deep.md":    "nested content",
+		"default/legacy.md": "legacy content",
+	}
+	for path, content := range legacyFiles {
+		fullPath := filepath.Join(tempDir, "knowledge", path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create legacy directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write legacy file: %v", err)
+		}
+	}
+
+	repo, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	for path, content := range legacyFiles {
+		readContent, err := repo.Read("default", path)
+		if err != nil {
+			t.Fatalf("Failed to read migrated knowledge %s: %v", path, err)
+		}
+		if readContent != content {
+			t.Errorf("Content mismatch for %s: got %q, want %q", path, readContent, content)
+		}
+	}
+
+	projects, err := repo.ListProjects()
+	if err != nil {
+		t.Fatalf("Failed to list projects: %v", err)
+	}
+	if len(projects) != 1 || projects[0] != "default" {
+		t.Errorf("Expected [default], got %v", projects)
+	}
+
+	// Reopening the repository must not migrate a second time
+	if err := repo.Write("other", "notes", "content"); err != nil {
+		t.Fatalf("Failed to write knowledge: %v", err)
+	}
+	reopened, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+	if _, err := reopened.Read("other", "notes"); err != nil {
+		t.Errorf("Expected project to survive reopening: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package knowledge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// reservedNames are device names that cannot be used as file names on Windows
+var reservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// resolveSegment validates a single path segment
+func resolveSegment(path string, segment string) error {
+	if segment == ".." {
+		return &contracts.InvalidPathError{Path: path, Err: contracts.ErrPathTraversal}
+	}
+	if strings.HasPrefix(segment, ".") {
+		return &contracts.InvalidPathError{Path: path, Err: contracts.ErrReservedName}
+	}
+
+	base := strings.ToLower(segment)
+	if i := strings.Index(base, "."); i >= 0 {
+		base = base[:i]
+	}
+	if reservedNames[base] || strings.ContainsAny(segment, "\x00:") {
+		return &contracts.InvalidPathError{Path: path, Err: contracts.ErrReservedName}
+	}
+
+	return nil
+}
+
+// resolvePath maps a model supplied relative path to a markdown file below root.
+// It rejects absolute paths, '..' segments, hidden or reserved names and paths
+// that leave root through a symlink.
+func resolvePath(root string, path string) (string, error) {
+	// Treat backslashes as separators, so Windows style paths can't sneak past the checks
+	normalizedPath := strings.ReplaceAll(path, `\`, "/")
+
+	if strings.TrimSpace(normalizedPath) == "" {
+		return "", &contracts.InvalidPathError{Path: path, Err: contracts.ErrEmptyPath}
+	}
+	if strings.HasPrefix(normalizedPath, "/") || filepath.IsAbs(path) || filepath.VolumeName(path) != "" {
+		return "", &contracts.InvalidPathError{Path: path, Err: contracts.ErrAbsolutePath}
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(normalizedPath, "/") {
+		if segment == "" || segment == "." {
+			continue
+		}
+		if err := resolveSegment(path, segment); err != nil {
+			return "", err
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		return "", &contracts.InvalidPathError{Path: path, Err: contracts.ErrEmptyPath}
+	}
+
+	// Add .md extension if not present
+	last := len(segments) - 1
+	if !strings.HasSuffix(segments[last], ".md") {
+		segments[last] += ".md"
+	}
+
+	fullPath := filepath.Join(append([]string{root}, segments...)...)
+
+	if err := checkWithinRoot(root, fullPath); err != nil {
+		return "", &contracts.InvalidPathError{Path: path, Err: err}
+	}
+
+	return fullPath, nil
+}
+
+// resolveProjectDir maps a project name to its directory below root
+func resolveProjectDir(root string, project string) (string, error) {
+	if project == "" || strings.ContainsAny(project, `/\`) {
+		return "", &contracts.InvalidPathError{Path: project, Err: contracts.ErrInvalidProject}
+	}
+	if err := resolveSegment(project, project); err != nil {
+		return "", err
+	}
+
+	projectDir := filepath.Join(root, project)
+	if err := checkWithinRoot(root, projectDir); err != nil {
+		return "", &contracts.InvalidPathError{Path: project, Err: err}
+	}
+
+	return projectDir, nil
+}
+
+// checkWithinRoot makes sure that the existing part of fullPath does not
+// leave root once symlinks are followed
+func checkWithinRoot(root string, fullPath string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Nothing exists yet, so there can't be a symlink either
+			return nil
+		}
+		return contracts.ErrSymlinkEscape
+	}
+
+	// Find the deepest part of the path that already exists
+	existing := fullPath
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		if existing == root || existing == filepath.Dir(existing) {
+			return nil
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	realPath, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		// A dangling symlink can't be followed safely
+		return contracts.ErrSymlinkEscape
+	}
+
+	rel, err := filepath.Rel(realRoot, realPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return contracts.ErrSymlinkEscape
+	}
+
+	return nil
+}
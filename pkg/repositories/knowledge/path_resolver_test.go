@@ -0,0 +1,135 @@
+package knowledge
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+func TestResolvePathRejectsHostilePaths(t *testing.T) {
+	root := t.TempDir()
+
+	tests := []struct {
+		path string
+		want error
+	}{
+		{"", contracts.ErrEmptyPath},
+		{"   ", contracts.ErrEmptyPath},
+		{"./", contracts.ErrEmptyPath},
+		{"/etc/passwd", contracts.ErrAbsolutePath},
+		{`\windows\system32`, contracts.ErrAbsolutePath},
+		{"../../.ssh/authorized_keys", contracts.ErrPathTraversal},
+		{"notes/../../secret", contracts.ErrPathTraversal},
+		{`..\..\secret`, contracts.ErrPathTraversal},
+		{"notes/..", contracts.ErrPathTraversal},
+		{".ssh/authorized_keys", contracts.ErrReservedName},
+		{"notes/.hidden", contracts.ErrReservedName},
+		{".projects", contracts.ErrReservedName},
+		{"CON", contracts.ErrReservedName},
+		{"notes/lpt1.md", contracts.ErrReservedName},
+		{"C:secret", contracts.ErrReservedName},
+		{"notes/a\x00b", contracts.ErrReservedName},
+	}
+
+	for _, tt := range tests {
+		_, err := resolvePath(root, tt.path)
+		if err == nil {
+			t.Errorf("Expected error for path %q", tt.path)
+			continue
+		}
+
+		var pathErr *contracts.InvalidPathError
+		if !errors.As(err, &pathErr) {
+			t.Errorf("Expected InvalidPathError for path %q, got %T", tt.path, err)
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Expected %v for path %q, got %v", tt.want, tt.path, err)
+		}
+	}
+}
+
+func TestResolvePathAcceptsRegularPaths(t *testing.T) {
+	root := t.TempDir()
+
+	tests := map[string]string{
+		"notes":               "notes.md",
+		"notes.md":            "notes.md",
+		"./notes":             "notes.md",
+		"deep/nested/notes":   "deep/nested/notes.md",
+		"deep//nested/./note": "deep/nested/note.md",
+		`windows\style`:       "windows/style.md",
+		"file.with.dots":      "file.with.dots.md",
+	}
+
+	for path, want := range tests {
+		fullPath, err := resolvePath(root, path)
+		if err != nil {
+			t.Errorf("Unexpected error for path %q: %v", path, err)
+			continue
+		}
+		if fullPath != filepath.Join(root, filepath.FromSlash(want)) {
+			t.Errorf("Expected %s for path %q, got %s", want, path, fullPath)
+		}
+	}
+}
+
+func TestResolvePathSymlinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(outside, "secret.md"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("Failed to write outside file: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "inside"), 0755); err != nil {
+		t.Fatalf("Failed to create inside directory: %v", err)
+	}
+
+	links := map[string]string{
+		"escape-dir":     outside,
+		"escape-file.md": filepath.Join(outside, "secret.md"),
+		"dangling.md":    filepath.Join(outside, "missing.md"),
+		"internal":       filepath.Join(root, "inside"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+
+	for _, path := range []string{"escape-dir/secret", "escape-dir/new/file", "escape-file", "dangling"} {
+		_, err := resolvePath(root, path)
+		if !errors.Is(err, contracts.ErrSymlinkEscape) {
+			t.Errorf("Expected symlink escape for path %q, got %v", path, err)
+		}
+	}
+
+	// Symlinks that stay inside the root are fine
+	if _, err := resolvePath(root, "internal/notes"); err != nil {
+		t.Errorf("Unexpected error for internal symlink: %v", err)
+	}
+}
+
+func TestFileRepositoryRejectsEscapingProject(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := t.TempDir()
+
+	repo, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	if err := os.Symlink(outside, filepath.Join(repo.baseDir, "linked")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	if err := repo.Write("linked", "notes", "content"); !errors.Is(err, contracts.ErrSymlinkEscape) {
+		t.Errorf("Expected symlink escape for project, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "notes.md")); !os.IsNotExist(err) {
+		t.Error("File was written outside of the knowledge directory")
+	}
+}
@@ -0,0 +1,218 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+	"github.com/mstrehse/mcp-brain/pkg/repositories/embedding"
+)
+
+// defaultRelatedLimit is the number of results returned when the query has no limit
+const defaultRelatedLimit = 5
+
+// vectorDocument holds the embedding of a single knowledge file
+type vectorDocument struct {
+	ModTime int64     `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Vector  []float32 `json:"vector"`
+}
+
+// vectorIndex is the on-disk store of embeddings of a project, keyed by relative path
+type vectorIndex struct {
+	Provider  string                     `json:"provider"`
+	Documents map[string]*vectorDocument `json:"documents"`
+}
+
+// vectorIndexPath returns the file the embeddings of a project are stored in
+func (r *FileRepository) vectorIndexPath(project string) string {
+	return filepath.Join(r.vectorDir, project+".json")
+}
+
+// loadVectorIndex loads the embeddings of a project, starting over if they are missing or
+// were created by another provider
+func (r *FileRepository) loadVectorIndex(project string) *vectorIndex {
+	index := &vectorIndex{
+		Provider:  r.embeddings.Name(),
+		Documents: make(map[string]*vectorDocument),
+	}
+
+	data, err := os.ReadFile(r.vectorIndexPath(project))
+	if err != nil {
+		return index
+	}
+
+	var stored vectorIndex
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Provider != r.embeddings.Name() || stored.Documents == nil {
+		// The embeddings are only a cache, so broken or foreign ones are simply rebuilt
+		return index
+	}
+
+	return &stored
+}
+
+// saveVectorIndex saves the embeddings of a project to disk
+func (r *FileRepository) saveVectorIndex(project string, index *vectorIndex) error {
+	if err := os.MkdirAll(r.vectorDir, 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vector index: %w", err)
+	}
+
+	if err := os.WriteFile(r.vectorIndexPath(project), data, 0644); err != nil {
+		return fmt.Errorf("failed to write vector index: %w", err)
+	}
+
+	return nil
+}
+
+// refreshVectorIndex embeds files that were added or changed since the last run and
+// drops removed ones. All outdated files are sent to the provider in a single batch.
+func (r *FileRepository) refreshVectorIndex(projectDir string, index *vectorIndex) (bool, error) {
+	files, err := markdownFiles(projectDir)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for relPath := range index.Documents {
+		if _, exists := files[relPath]; !exists {
+			delete(index.Documents, relPath)
+			changed = true
+		}
+	}
+
+	var outdated []string
+	var contents []string
+	for relPath, info := range files {
+		if document, exists := index.Documents[relPath]; exists && document.ModTime == info.ModTime().UnixNano() && document.Size == info.Size() {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return false, fmt.Errorf("failed to read file: %w", err)
+		}
+		outdated = append(outdated, relPath)
+		contents = append(contents, string(content))
+	}
+	if len(outdated) == 0 {
+		return changed, nil
+	}
+
+	vectors, err := r.embeddings.Embed(contents)
+	if err != nil {
+		return false, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	if len(vectors) != len(outdated) {
+		return false, fmt.Errorf("embedding provider returned %d vectors for %d texts", len(vectors), len(outdated))
+	}
+
+	for i, relPath := range outdated {
+		info := files[relPath]
+		index.Documents[relPath] = &vectorDocument{
+			ModTime: info.ModTime().UnixNano(),
+			Size:    info.Size(),
+			Vector:  vectors[i],
+		}
+	}
+
+	return true, nil
+}
+
+// Related returns the knowledge files of a project that are most similar to a text or
+// to an existing knowledge file
+func (r *FileRepository) Related(project string, query contracts.RelatedQuery) ([]*contracts.RelatedResult, error) {
+	projectDir, err := r.projectDir(project)
+	if err != nil {
+		return nil, err
+	}
+
+	if (query.Text == "") == (query.Path == "") {
+		return nil, fmt.Errorf("either a text or a path is required")
+	}
+
+	// Resolve the reference file before touching the index, so hostile paths are rejected early
+	var referencePath string
+	if query.Path != "" {
+		fullPath, err := resolvePath(projectDir, query.Path)
+		if err != nil {
+			return nil, err
+		}
+		relPath, err := filepath.Rel(projectDir, fullPath)
+		if err != nil {
+			return nil, err
+		}
+		referencePath = filepath.ToSlash(relPath)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultRelatedLimit
+	}
+
+	r.mutex.Lock()
+	index := r.loadVectorIndex(project)
+	changed, err := r.refreshVectorIndex(projectDir, index)
+	if err == nil && changed {
+		err = r.saveVectorIndex(project, index)
+	}
+	r.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var reference []float32
+	if referencePath != "" {
+		document, exists := index.Documents[referencePath]
+		if !exists {
+			return nil, fmt.Errorf("knowledge file not found: %s", query.Path)
+		}
+		reference = document.Vector
+	} else {
+		vectors, err := r.embeddings.Embed([]string{query.Text})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embedding: %w", err)
+		}
+		if len(vectors) != 1 {
+			return nil, fmt.Errorf("embedding provider returned %d vectors for 1 text", len(vectors))
+		}
+		reference = vectors[0]
+	}
+
+	results := []*contracts.RelatedResult{}
+	for path, document := range index.Documents {
+		if path == referencePath {
+			continue
+		}
+
+		similarity := embedding.Cosine(reference, document.Vector)
+		if similarity <= 0 {
+			continue
+		}
+
+		results = append(results, &contracts.RelatedResult{
+			Path:       path,
+			Similarity: math.Round(similarity*1000) / 1000,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Similarity != results[j].Similarity {
+			return results[i].Similarity > results[j].Similarity
+		}
+		return results[i].Path < results[j].Path
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
@@ -0,0 +1,156 @@
+package knowledge
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+// countingProvider records how many texts were embedded
+type countingProvider struct {
+	embedded int
+}
+
+func (p *countingProvider) Name() string {
+	return "counting"
+}
+
+func (p *countingProvider) Embed(texts []string) ([][]float32, error) {
+	p.embedded += len(texts)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text)), 1}
+	}
+	return vectors, nil
+}
+
+func TestFileRepositoryRelated(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	files := map[string]string{
+		"auth/authentication": "The authentication flow validates credentials and issues session tokens.",
+		"auth/authenticated":  "Authenticated users receive session tokens after validating credentials.",
+		"cooking/pie":         "Bake the apple pie for forty minutes.",
+	}
+	for path, content := range files {
+		if err := repo.Write("test-project", path, content); err != nil {
+			t.Fatalf("Failed to write knowledge: %v", err)
+		}
+	}
+
+	t.Run("related to text", func(t *testing.T) {
+		results, err := repo.Related("test-project", contracts.RelatedQuery{Text: "how are credentials authenticated"})
+		if err != nil {
+			t.Fatalf("Failed to find related knowledge: %v", err)
+		}
+		if len(results) == 0 || results[0].Path == "cooking/pie.md" {
+			t.Errorf("Expected auth knowledge first, got %v", results)
+		}
+	})
+
+	t.Run("related to path", func(t *testing.T) {
+		results, err := repo.Related("test-project", contracts.RelatedQuery{Path: "auth/authentication"})
+		if err != nil {
+			t.Fatalf("Failed to find related knowledge: %v", err)
+		}
+		if len(results) == 0 || results[0].Path != "auth/authenticated.md" {
+			t.Errorf("Expected auth/authenticated.md first, got %v", results)
+		}
+		for _, result := range results {
+			if result.Path == "auth/authentication.md" {
+				t.Error("Expected the reference file to be excluded")
+			}
+		}
+	})
+
+	t.Run("limits results", func(t *testing.T) {
+		results, err := repo.Related("test-project", contracts.RelatedQuery{Text: "tokens", Limit: 1})
+		if err != nil {
+			t.Fatalf("Failed to find related knowledge: %v", err)
+		}
+		if len(results) > 1 {
+			t.Errorf("Expected at most 1 result, got %d", len(results))
+		}
+	})
+
+	t.Run("requires text or path", func(t *testing.T) {
+		if _, err := repo.Related("test-project", contracts.RelatedQuery{}); err == nil {
+			t.Error("Expected error without text and path")
+		}
+		if _, err := repo.Related("test-project", contracts.RelatedQuery{Text: "a", Path: "b"}); err == nil {
+			t.Error("Expected error with both text and path")
+		}
+	})
+
+	t.Run("rejects hostile reference path", func(t *testing.T) {
+		_, err := repo.Related("test-project", contracts.RelatedQuery{Path: "../../etc/passwd"})
+		if !errors.Is(err, contracts.ErrPathTraversal) {
+			t.Errorf("Expected path traversal error, got %v", err)
+		}
+	})
+
+	t.Run("unknown reference path", func(t *testing.T) {
+		if _, err := repo.Related("test-project", contracts.RelatedQuery{Path: "missing"}); err == nil {
+			t.Error("Expected error for missing reference file")
+		}
+	})
+}
+
+func TestFileRepositoryRelatedEmbedsIncrementally(t *testing.T) {
+	tempDir := t.TempDir()
+	provider := &countingProvider{}
+	repo, err := NewFileRepositoryWithEmbeddings(tempDir, provider)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	for _, path := range []string{"a", "b", "c"} {
+		if err := repo.Write("test-project", path, "content of "+path); err != nil {
+			t.Fatalf("Failed to write knowledge: %v", err)
+		}
+	}
+
+	if _, err := repo.Related("test-project", contracts.RelatedQuery{Path: "a"}); err != nil {
+		t.Fatalf("Failed to find related knowledge: %v", err)
+	}
+	if provider.embedded != 3 {
+		t.Errorf("Expected 3 embedded texts, got %d", provider.embedded)
+	}
+
+	// The embeddings are kept alongside the knowledge directory
+	if _, err := os.Stat(filepath.Join(tempDir, "index", "vectors", "test-project.json")); err != nil {
+		t.Fatalf("Expected vector index on disk: %v", err)
+	}
+
+	// Only changed files are embedded again
+	if err := repo.Write("test-project", "b", "changed content of b"); err != nil {
+		t.Fatalf("Failed to write knowledge: %v", err)
+	}
+	if _, err := repo.Related("test-project", contracts.RelatedQuery{Path: "a"}); err != nil {
+		t.Fatalf("Failed to find related knowledge: %v", err)
+	}
+	if provider.embedded != 4 {
+		t.Errorf("Expected 4 embedded texts, got %d", provider.embedded)
+	}
+
+	// Switching the provider rebuilds the embeddings
+	reopened, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+	results, err := reopened.Related("test-project", contracts.RelatedQuery{Path: "a"})
+	if err != nil {
+		t.Fatalf("Failed to find related knowledge: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected 2 results after rebuilding, got %v", results)
+	}
+}
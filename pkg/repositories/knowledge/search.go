@@ -0,0 +1,349 @@
+package knowledge
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+	"github.com/mstrehse/mcp-brain/pkg/internal/textsearch"
+)
+
+const (
+	// searchIndexVersion is bumped whenever the on-disk index format or tokenizer changes
+	searchIndexVersion = 1
+
+	// defaultSearchLimit is the number of results returned when the query has no limit
+	defaultSearchLimit = 10
+
+	// maxSnippets is the number of matching lines returned per result
+	maxSnippets = 3
+
+	// maxSnippetLength is the number of characters a snippet is cut to
+	maxSnippetLength = 200
+
+	// BM25 tuning parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// searchDocument holds the term frequencies of a single knowledge file
+type searchDocument struct {
+	ModTime int64          `json:"mod_time"`
+	Size    int64          `json:"size"`
+	Length  int            `json:"length"`
+	Terms   map[string]int `json:"terms"`
+}
+
+// searchIndex is the on-disk inverted index of a project, keyed by relative path
+type searchIndex struct {
+	Version   int                        `json:"version"`
+	Documents map[string]*searchDocument `json:"documents"`
+}
+
+// newSearchDocument builds the index entry of a knowledge file
+func newSearchDocument(info os.FileInfo, content string) *searchDocument {
+	terms := textsearch.Tokenize(content)
+	document := &searchDocument{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Length:  len(terms),
+		Terms:   make(map[string]int),
+	}
+	for _, term := range terms {
+		document.Terms[term]++
+	}
+	return document
+}
+
+// searchIndexPath returns the file the search index of a project is stored in
+func (r *FileRepository) searchIndexPath(project string) string {
+	return filepath.Join(r.searchDir, project+".json")
+}
+
+// loadSearchIndex loads the search index of a project, starting over if it is missing or outdated
+func (r *FileRepository) loadSearchIndex(project string) *searchIndex {
+	index := &searchIndex{
+		Version:   searchIndexVersion,
+		Documents: make(map[string]*searchDocument),
+	}
+
+	data, err := os.ReadFile(r.searchIndexPath(project))
+	if err != nil {
+		return index
+	}
+
+	var stored searchIndex
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != searchIndexVersion || stored.Documents == nil {
+		// The index is only a cache, so a broken one is simply rebuilt
+		return index
+	}
+
+	return &stored
+}
+
+// saveSearchIndex saves the search index of a project to disk
+func (r *FileRepository) saveSearchIndex(project string, index *searchIndex) error {
+	if err := os.MkdirAll(r.searchDir, 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal search index: %w", err)
+	}
+
+	if err := os.WriteFile(r.searchIndexPath(project), data, 0644); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	return nil
+}
+
+// markdownFiles returns the file info of every markdown file of a project, keyed by relative path
+func markdownFiles(projectDir string) (map[string]os.FileInfo, error) {
+	files := make(map[string]os.FileInfo)
+
+	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".md") {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = info
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	return files, nil
+}
+
+// refreshSearchIndex brings the index in line with the files of a project.
+// Only files that were added, changed or removed since the last run are processed.
+func (r *FileRepository) refreshSearchIndex(projectDir string, index *searchIndex) (bool, error) {
+	files, err := markdownFiles(projectDir)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for relPath, info := range files {
+		if document, exists := index.Documents[relPath]; exists && document.ModTime == info.ModTime().UnixNano() && document.Size == info.Size() {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return false, fmt.Errorf("failed to read file: %w", err)
+		}
+		index.Documents[relPath] = newSearchDocument(info, string(content))
+		changed = true
+	}
+
+	for relPath := range index.Documents {
+		if _, exists := files[relPath]; !exists {
+			delete(index.Documents, relPath)
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// updateSearchIndex updates the index entry of a single file after it was written or deleted
+func (r *FileRepository) updateSearchIndex(project string, fullPath string) error {
+	projectDir, err := r.projectDir(project)
+	if err != nil {
+		return err
+	}
+
+	relPath, err := filepath.Rel(projectDir, fullPath)
+	if err != nil {
+		return err
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	index := r.loadSearchIndex(project)
+
+	info, err := os.Stat(fullPath)
+	if os.IsNotExist(err) {
+		delete(index.Documents, relPath)
+	} else if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	} else {
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		index.Documents[relPath] = newSearchDocument(info, string(content))
+	}
+
+	return r.saveSearchIndex(project, index)
+}
+
+// Search returns the knowledge files of a project ranked by relevance to the query using BM25
+func (r *FileRepository) Search(project string, query contracts.SearchQuery) ([]*contracts.SearchResult, error) {
+	projectDir, err := r.projectDir(project)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := textsearch.UniqueTerms(textsearch.Tokenize(query.Query))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query must contain at least one word")
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	prefix := strings.TrimPrefix(strings.TrimLeft(filepath.ToSlash(query.PathPrefix), "/"), "./")
+
+	r.mutex.Lock()
+	index := r.loadSearchIndex(project)
+	changed, err := r.refreshSearchIndex(projectDir, index)
+	if err == nil && changed {
+		err = r.saveSearchIndex(project, index)
+	}
+	r.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	results := scoreDocuments(index, terms, prefix)
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	for _, result := range results {
+		snippets, err := findSnippets(filepath.Join(projectDir, filepath.FromSlash(result.Path)), terms)
+		if err != nil {
+			return nil, err
+		}
+		result.Snippets = snippets
+	}
+
+	return results, nil
+}
+
+// scoreDocuments computes the BM25 score of every document below prefix that contains a query term
+func scoreDocuments(index *searchIndex, terms []string, prefix string) []*contracts.SearchResult {
+	documentCount := float64(len(index.Documents))
+	if documentCount == 0 {
+		return []*contracts.SearchResult{}
+	}
+
+	totalLength := 0
+	documentFrequency := make(map[string]int)
+	for _, document := range index.Documents {
+		totalLength += document.Length
+		for _, term := range terms {
+			if document.Terms[term] > 0 {
+				documentFrequency[term]++
+			}
+		}
+	}
+	averageLength := float64(totalLength) / documentCount
+	if averageLength == 0 {
+		averageLength = 1
+	}
+
+	results := []*contracts.SearchResult{}
+	for path, document := range index.Documents {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		score := 0.0
+		for _, term := range terms {
+			frequency := float64(document.Terms[term])
+			if frequency == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (documentCount-df+0.5)/(df+0.5))
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(document.Length)/averageLength)
+			score += idf * frequency * (bm25K1 + 1) / (frequency + norm)
+		}
+
+		if score > 0 {
+			results = append(results, &contracts.SearchResult{
+				Path:  path,
+				Score: math.Round(score*1000) / 1000,
+			})
+		}
+	}
+
+	return results
+}
+
+// findSnippets returns the first lines of a file that contain one of the terms
+func findSnippets(fullPath string, terms []string) ([]contracts.Snippet, error) {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	snippets := []contracts.Snippet{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan() && len(snippets) < maxSnippets; line++ {
+		text := scanner.Text()
+		for _, token := range textsearch.Tokenize(text) {
+			if wanted[token] {
+				snippets = append(snippets, contracts.Snippet{
+					Line: line,
+					Text: truncate(strings.TrimSpace(text), maxSnippetLength),
+				})
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return snippets, nil
+}
+
+// truncate cuts text to at most limit characters
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…"
+}
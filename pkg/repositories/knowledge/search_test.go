@@ -0,0 +1,171 @@
+package knowledge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
+)
+
+func TestFileRepositorySearch(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	files := map[string]string{
+		"auth/login":        "# Login\n\nThe login flow uses OAuth tokens.\nTokens expire after one hour.",
+		"auth/tokens":       "# Tokens\n\nRefresh tokens are rotated.",
+		"architecture/db":   "# Database\n\nWe use PostgreSQL for persistence.",
+		"architecture/api":  "# API\n\nThe API exposes a login endpoint.",
+		"unrelated/weather": "# Weather\n\nIt is sunny.",
+	}
+	for path, content := range files {
+		if err := repo.Write("test-project", path, content); err != nil {
+			t.Fatalf("Failed to write knowledge: %v", err)
+		}
+	}
+	if err := repo.Write("other-project", "auth/login", "login login login"); err != nil {
+		t.Fatalf("Failed to write knowledge: %v", err)
+	}
+
+	t.Run("ranks by relevance", func(t *testing.T) {
+		results, err := repo.Search("test-project", contracts.SearchQuery{Query: "login tokens"})
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results, got %d", len(results))
+		}
+		if results[0].Path != "auth/login.md" {
+			t.Errorf("Expected auth/login.md first, got %s", results[0].Path)
+		}
+		for i := 1; i < len(results); i++ {
+			if results[i].Score > results[i-1].Score {
+				t.Errorf("Results are not sorted by score: %v", results)
+			}
+		}
+	})
+
+	t.Run("returns snippets with line numbers", func(t *testing.T) {
+		results, err := repo.Search("test-project", contracts.SearchQuery{Query: "PostgreSQL"})
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("Expected 1 result, got %d", len(results))
+		}
+		if len(results[0].Snippets) != 1 {
+			t.Fatalf("Expected 1 snippet, got %d", len(results[0].Snippets))
+		}
+		snippet := results[0].Snippets[0]
+		if snippet.Line != 3 || snippet.Text != "We use PostgreSQL for persistence." {
+			t.Errorf("Unexpected snippet: %+v", snippet)
+		}
+	})
+
+	t.Run("filters by path prefix", func(t *testing.T) {
+		results, err := repo.Search("test-project", contracts.SearchQuery{Query: "login", PathPrefix: "architecture/"})
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(results) != 1 || results[0].Path != "architecture/api.md" {
+			t.Errorf("Expected only architecture/api.md, got %v", results)
+		}
+	})
+
+	t.Run("limits results", func(t *testing.T) {
+		results, err := repo.Search("test-project", contracts.SearchQuery{Query: "login tokens", Limit: 1})
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("Expected 1 result, got %d", len(results))
+		}
+	})
+
+	t.Run("keeps projects apart", func(t *testing.T) {
+		results, err := repo.Search("other-project", contracts.SearchQuery{Query: "tokens"})
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no results, got %v", results)
+		}
+	})
+
+	t.Run("rejects empty query", func(t *testing.T) {
+		if _, err := repo.Search("test-project", contracts.SearchQuery{Query: " -- "}); err == nil {
+			t.Error("Expected error for query without words")
+		}
+	})
+}
+
+func TestFileRepositorySearchIndexMaintenance(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	if err := repo.Write("test-project", "notes", "alpha"); err != nil {
+		t.Fatalf("Failed to write knowledge: %v", err)
+	}
+
+	// The index is kept on disk
+	if _, err := os.Stat(filepath.Join(tempDir, "index", "search", "test-project.json")); err != nil {
+		t.Fatalf("Expected search index on disk: %v", err)
+	}
+
+	// Writes update the index
+	if err := repo.Write("test-project", "notes", "beta"); err != nil {
+		t.Fatalf("Failed to write knowledge: %v", err)
+	}
+	results, err := repo.Search("test-project", contracts.SearchQuery{Query: "alpha"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected overwritten content to be gone, got %v", results)
+	}
+
+	// Files changed outside of the repository are picked up
+	externalPath := filepath.Join(repo.baseDir, "test-project", "external.md")
+	if err := os.WriteFile(externalPath, []byte("gamma"), 0644); err != nil {
+		t.Fatalf("Failed to write external file: %v", err)
+	}
+	results, err = repo.Search("test-project", contracts.SearchQuery{Query: "gamma"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != "external.md" {
+		t.Errorf("Expected external.md, got %v", results)
+	}
+
+	// Deletes remove documents from the index
+	if err := repo.Delete("test-project", "notes"); err != nil {
+		t.Fatalf("Failed to delete knowledge: %v", err)
+	}
+	results, err = repo.Search("test-project", contracts.SearchQuery{Query: "beta"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected deleted content to be gone, got %v", results)
+	}
+
+	// A broken index is rebuilt
+	if err := os.WriteFile(filepath.Join(tempDir, "index", "search", "test-project.json"), []byte("{broken"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt index: %v", err)
+	}
+	results, err = repo.Search("test-project", contracts.SearchQuery{Query: "gamma"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected rebuilt index to find external.md, got %v", results)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,38 +13,220 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// TasksFile represents the structure of the tasks.yaml file
+// TasksFile represents the structure of the YAML file of a task queue
 type TasksFile struct {
 	Tasks      []*contracts.Task `yaml:"tasks"`
+	LastID     int               `yaml:"last_id"`
 	LastUpdate time.Time         `yaml:"last_update"`
 }
 
-// FileRepository handles file-based storage for tasks using a single YAML file
+// nextID hands out the next stable task ID
+func (f *TasksFile) nextID() string {
+	f.LastID++
+	return fmt.Sprintf("task-%d", f.LastID)
+}
+
+// findTask returns the task with the given ID
+func (f *TasksFile) findTask(id string) *contracts.Task {
+	for _, task := range f.Tasks {
+		if task.ID == id {
+			return task
+		}
+	}
+	return nil
+}
+
+// isDescendant reports whether a task is below the task with the given ID
+func (f *TasksFile) isDescendant(task *contracts.Task, ancestorID string) bool {
+	// Guard against broken files with parent cycles
+	for depth := 0; task != nil && task.ParentID != "" && depth < len(f.Tasks); depth++ {
+		if task.ParentID == ancestorID {
+			return true
+		}
+		task = f.findTask(task.ParentID)
+	}
+	return false
+}
+
+// subtreeEnd returns the queue position right after a task and all of its descendants
+func (f *TasksFile) subtreeEnd(id string) int {
+	end := len(f.Tasks)
+	for i, task := range f.Tasks {
+		if task.ID == id || f.isDescendant(task, id) {
+			end = i + 1
+		}
+	}
+	return end
+}
+
+// insertIndex returns the queue index tasks are put at according to a placement
+func (f *TasksFile) insertIndex(placement contracts.TaskPlacement) (int, error) {
+	options := 0
+	if placement.Front {
+		options++
+	}
+	if placement.After != "" {
+		options++
+	}
+	if placement.Position != 0 {
+		options++
+	}
+	if options > 1 {
+		return 0, fmt.Errorf("use only one of front, after and position")
+	}
+
+	switch {
+	case placement.Front:
+		return 0, nil
+	case placement.After != "":
+		if f.findTask(placement.After) == nil {
+			return 0, fmt.Errorf("task not found: %s", placement.After)
+		}
+		return f.subtreeEnd(placement.After), nil
+	case placement.Position != 0:
+		if placement.Position < 1 || placement.Position > len(f.Tasks)+1 {
+			return 0, fmt.Errorf("position %d is out of range (1-%d)", placement.Position, len(f.Tasks)+1)
+		}
+		return placement.Position - 1, nil
+	}
+	return len(f.Tasks), nil
+}
+
+// rollup finishes parents once all of their subtasks are finished. A parent is done
+// when its subtasks are done or cancelled, and failed when one of them failed.
+func (f *TasksFile) rollup(parentID string, now time.Time) {
+	for parentID != "" {
+		parent := f.findTask(parentID)
+		if parent == nil || parent.Status.IsFinished() {
+			return
+		}
+
+		var failed []string
+		for _, task := range f.Tasks {
+			if task.ParentID != parentID {
+				continue
+			}
+			if !task.Status.IsFinished() {
+				return
+			}
+			if task.Status == contracts.TaskStatusFailed {
+				failed = append(failed, task.ID)
+			}
+		}
+
+		if len(failed) > 0 {
+			parent.Status = contracts.TaskStatusFailed
+			parent.Outcome = "Subtasks failed: " + strings.Join(failed, ", ")
+		} else {
+			parent.Status = contracts.TaskStatusDone
+			parent.Outcome = "All subtasks are done"
+		}
+		parent.CompletedAt = &now
+		parentID = parent.ParentID
+	}
+}
+
+// updateBlocked records the unfinished dependencies of every pending task
+func (f *TasksFile) updateBlocked() {
+	status := make(map[string]contracts.TaskStatus, len(f.Tasks))
+	for _, task := range f.Tasks {
+		status[task.ID] = task.Status
+	}
+
+	for _, task := range f.Tasks {
+		task.BlockedBy = nil
+		if task.Status != contracts.TaskStatusPending {
+			continue
+		}
+		for _, dependency := range task.DependsOn {
+			if status[dependency] != contracts.TaskStatusDone {
+				task.BlockedBy = append(task.BlockedBy, dependency)
+			}
+		}
+	}
+}
+
+// findCycle returns the IDs of a dependency cycle, or nil if the tasks form a DAG.
+// Parents count as depending on their subtasks, as they only finish with them,
+// so a subtask depending on one of its ancestors is a cycle too.
+func (f *TasksFile) findCycle() []string {
+	dependencies := make(map[string][]string, len(f.Tasks))
+	for _, task := range f.Tasks {
+		dependencies[task.ID] = append(dependencies[task.ID], task.DependsOn...)
+		if task.ParentID != "" {
+			dependencies[task.ParentID] = append(dependencies[task.ParentID], task.ID)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(f.Tasks))
+	var path []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		switch state[id] {
+		case visiting:
+			// Cut the path down to the cycle itself
+			for i, pathID := range path {
+				if pathID == id {
+					return append(append([]string{}, path[i:]...), id)
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		state[id] = visiting
+		path = append(path, id)
+		for _, dependency := range dependencies[id] {
+			if cycle := visit(dependency); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = visited
+		return nil
+	}
+
+	for _, task := range f.Tasks {
+		if cycle := visit(task.ID); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// FileRepository handles file-based storage for tasks using one YAML file per queue
 type FileRepository struct {
-	filePath string
-	mutex    sync.RWMutex
+	queuesDir  string
+	archiveDir string
+	mutex      sync.RWMutex
 }
 
 // NewFileRepository creates a new file-based task repository
 func NewFileRepository(baseDir string) (*FileRepository, error) {
-	// Ensure the brain directory exists
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create brain directory: %w", err)
+	repo := &FileRepository{
+		queuesDir:  filepath.Join(baseDir, "tasks"),
+		archiveDir: filepath.Join(baseDir, "tasks", "archive"),
 	}
 
-	filePath := filepath.Join(baseDir, "tasks.yaml")
-
-	repo := &FileRepository{
-		filePath: filePath,
+	// Ensure the queue directory exists
+	if err := os.MkdirAll(repo.queuesDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tasks directory: %w", err)
 	}
 
-	// Initialize file if it doesn't exist
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		if err := repo.saveTasksFile(&TasksFile{
-			Tasks:      []*contracts.Task{},
-			LastUpdate: time.Now(),
-		}); err != nil {
-			return nil, fmt.Errorf("failed to initialize tasks file: %w", err)
+	// The single tasks file of earlier versions becomes the default queue
+	legacyPath := filepath.Join(baseDir, "tasks.yaml")
+	defaultPath := repo.queuePath(contracts.DefaultQueue)
+	if _, err := os.Stat(legacyPath); err == nil {
+		if _, err := os.Stat(defaultPath); os.IsNotExist(err) {
+			if err := os.Rename(legacyPath, defaultPath); err != nil {
+				return nil, fmt.Errorf("failed to migrate tasks file: %w", err)
+			}
 		}
 	}
 
@@ -54,9 +238,18 @@ func (r *FileRepository) Close() error {
 	return nil
 }
 
-// loadTasksFile loads the tasks file from disk
-func (r *FileRepository) loadTasksFile() (*TasksFile, error) {
-	data, err := os.ReadFile(r.filePath)
+// queuePath returns the file a queue is stored in
+func (r *FileRepository) queuePath(queue string) string {
+	return filepath.Join(r.queuesDir, queue+".yaml")
+}
+
+// loadTasksFile loads the tasks file of a queue from disk
+func (r *FileRepository) loadTasksFile(queue string) (*TasksFile, error) {
+	if err := contracts.ValidateQueueName(queue); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(r.queuePath(queue))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &TasksFile{
@@ -72,11 +265,22 @@ func (r *FileRepository) loadTasksFile() (*TasksFile, error) {
 		return nil, fmt.Errorf("failed to unmarshal tasks file: %w", err)
 	}
 
+	// Tasks written before tasks had an identity are still waiting in the queue
+	for _, task := range tasksFile.Tasks {
+		if task.ID == "" {
+			task.ID = tasksFile.nextID()
+		}
+		if task.Status == "" {
+			task.Status = contracts.TaskStatusPending
+		}
+	}
+	tasksFile.updateBlocked()
+
 	return &tasksFile, nil
 }
 
-// saveTasksFile saves the tasks file to disk
-func (r *FileRepository) saveTasksFile(tasksFile *TasksFile) error {
+// saveTasksFile saves the tasks file of a queue to disk
+func (r *FileRepository) saveTasksFile(queue string, tasksFile *TasksFile) error {
 	tasksFile.LastUpdate = time.Now()
 
 	data, err := yaml.Marshal(tasksFile)
@@ -84,105 +288,408 @@ func (r *FileRepository) saveTasksFile(tasksFile *TasksFile) error {
 		return fmt.Errorf("failed to marshal tasks file: %w", err)
 	}
 
-	if err := os.WriteFile(r.filePath, data, 0644); err != nil {
+	if err := os.WriteFile(r.queuePath(queue), data, 0644); err != nil {
 		return fmt.Errorf("failed to write tasks file: %w", err)
 	}
 
 	return nil
 }
 
-// AddTasks adds multiple tasks to the queue
-func (r *FileRepository) AddTasks(contents []string) ([]*contracts.Task, error) {
-	if len(contents) == 0 {
+// ListQueues returns all active task queues sorted by name
+func (r *FileRepository) ListQueues() ([]*contracts.TaskQueue, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entries, err := os.ReadDir(r.queuesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tasks directory: %w", err)
+	}
+
+	queues := []*contracts.TaskQueue{}
+	for _, entry := range entries {
+		name, isQueue := strings.CutSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || !isQueue || contracts.ValidateQueueName(name) != nil {
+			continue
+		}
+
+		tasksFile, err := r.loadTasksFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		queue := &contracts.TaskQueue{
+			Name:       name,
+			Total:      len(tasksFile.Tasks),
+			LastUpdate: tasksFile.LastUpdate,
+		}
+		for _, task := range tasksFile.Tasks {
+			switch task.Status {
+			case contracts.TaskStatusPending:
+				queue.Pending++
+			case contracts.TaskStatusInProgress:
+				queue.InProgress++
+			}
+		}
+		queues = append(queues, queue)
+	}
+
+	return queues, nil
+}
+
+// ArchiveQueue moves the file of a queue into the archive, keeping it for reference
+func (r *FileRepository) ArchiveQueue(queue string) error {
+	if err := contracts.ValidateQueueName(queue); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	queuePath := r.queuePath(queue)
+	if _, err := os.Stat(queuePath); os.IsNotExist(err) {
+		return fmt.Errorf("queue not found: %s", queue)
+	}
+
+	if err := os.MkdirAll(r.archiveDir, 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	archivePath := filepath.Join(r.archiveDir, queue+"-"+time.Now().Format("20060102-150405.000000000")+".yaml")
+	if err := os.Rename(queuePath, archivePath); err != nil {
+		return fmt.Errorf("failed to archive queue: %w", err)
+	}
+
+	return nil
+}
+
+// AddTasks adds multiple tasks to the queue, rejecting unknown or cyclic dependencies
+func (r *FileRepository) AddTasks(queue string, newTasks []contracts.NewTask) ([]*contracts.Task, error) {
+	return r.insertTasks(queue, "", newTasks, contracts.TaskPlacement{})
+}
+
+// InsertTasks adds multiple tasks to the queue at the given placement
+func (r *FileRepository) InsertTasks(queue string, newTasks []contracts.NewTask, placement contracts.TaskPlacement) ([]*contracts.Task, error) {
+	return r.insertTasks(queue, "", newTasks, placement)
+}
+
+// AddSubtasks splits a task by adding subtasks that run before the tasks queued after it
+func (r *FileRepository) AddSubtasks(queue string, parentID string, newTasks []contracts.NewTask) ([]*contracts.Task, error) {
+	if parentID == "" {
+		return nil, fmt.Errorf("parent task ID is required")
+	}
+	return r.insertTasks(queue, parentID, newTasks, contracts.TaskPlacement{})
+}
+
+// insertTasks adds tasks to the queue at a placement, or below a parent right after its existing subtasks
+func (r *FileRepository) insertTasks(queue string, parentID string, newTasks []contracts.NewTask, placement contracts.TaskPlacement) ([]*contracts.Task, error) {
+	if len(newTasks) == 0 {
 		return []*contracts.Task{}, nil
 	}
 
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	tasksFile, err := r.loadTasksFile()
+	tasksFile, err := r.loadTasksFile(queue)
 	if err != nil {
 		return nil, err
 	}
 
-	var newTasks []*contracts.Task
-	now := time.Now()
+	var position int
+	var parent *contracts.Task
+	if parentID != "" {
+		parent = tasksFile.findTask(parentID)
+		if parent == nil {
+			return nil, fmt.Errorf("task not found: %s", parentID)
+		}
+		if parent.Status.IsFinished() {
+			return nil, fmt.Errorf("task %s is already %s", parentID, parent.Status)
+		}
+		position = tasksFile.subtreeEnd(parentID)
+	} else if position, err = tasksFile.insertIndex(placement); err != nil {
+		return nil, err
+	}
 
-	for _, content := range contents {
-		task := &contracts.Task{
-			Content:   content,
+	// Hand out IDs first, so tasks can depend on others of the same call
+	tasks := make([]*contracts.Task, len(newTasks))
+	now := time.Now()
+	for i, newTask := range newTasks {
+		tasks[i] = &contracts.Task{
+			ID:        tasksFile.nextID(),
+			Content:   newTask.Content,
+			Status:    contracts.TaskStatusPending,
+			Priority:  newTask.Priority,
+			ParentID:  parentID,
 			CreatedAt: now,
 		}
-		newTasks = append(newTasks, task)
-		tasksFile.Tasks = append(tasksFile.Tasks, task)
+		// Subtasks are handed out with at least the priority of the task they split
+		if parent != nil && parent.Priority > tasks[i].Priority {
+			tasks[i].Priority = parent.Priority
+		}
 	}
 
-	if err := r.saveTasksFile(tasksFile); err != nil {
+	for i, newTask := range newTasks {
+		references := newTask.DependsOn
+		if parent != nil {
+			// Subtasks of a blocked task wait for the same unfinished dependencies
+			references = append(append([]string{}, references...), parent.BlockedBy...)
+		}
+		dependsOn, err := resolveDependencies(tasksFile, tasks, references)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		tasks[i].DependsOn = dependsOn
+	}
+
+	tasksFile.Tasks = append(tasksFile.Tasks[:position], append(tasks, tasksFile.Tasks[position:]...)...)
+
+	if cycle := tasksFile.findCycle(); cycle != nil {
+		return nil, fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+	}
+
+	// A task that was split is worked on through its subtasks
+	if parent != nil && parent.Status == contracts.TaskStatusPending {
+		parent.Status = contracts.TaskStatusInProgress
+		parent.StartedAt = &now
+	}
+	tasksFile.updateBlocked()
+
+	if err := r.saveTasksFile(queue, tasksFile); err != nil {
 		return nil, err
 	}
 
-	return newTasks, nil
+	return tasks, nil
+}
+
+// resolveDependencies maps dependency references to task IDs. A reference is
+// either the ID of a queued task or "#n" for the n-th task of the same call.
+func resolveDependencies(tasksFile *TasksFile, batch []*contracts.Task, references []string) ([]string, error) {
+	var dependsOn []string
+	seen := make(map[string]bool)
+
+	for _, reference := range references {
+		id := reference
+		if strings.HasPrefix(reference, "#") {
+			position, err := strconv.Atoi(reference[1:])
+			if err != nil || position < 1 || position > len(batch) {
+				return nil, fmt.Errorf("invalid dependency reference: %s", reference)
+			}
+			id = batch[position-1].ID
+		} else if tasksFile.findTask(id) == nil {
+			return nil, fmt.Errorf("unknown dependency: %s", reference)
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			dependsOn = append(dependsOn, id)
+		}
+	}
+
+	return dependsOn, nil
 }
 
-// GetTask retrieves and removes the next pending task from the queue
-func (r *FileRepository) GetTask() (*contracts.Task, error) {
+// MoveTask moves a task together with its subtasks to another place in the queue
+func (r *FileRepository) MoveTask(queue string, id string, placement contracts.TaskPlacement) (*contracts.Task, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	tasksFile, err := r.loadTasksFile()
+	tasksFile, err := r.loadTasksFile(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	task := tasksFile.findTask(id)
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+	if task.Status.IsFinished() {
+		return nil, fmt.Errorf("task %s is already %s", id, task.Status)
+	}
+	if placement.After != "" {
+		if after := tasksFile.findTask(placement.After); placement.After == id || (after != nil && tasksFile.isDescendant(after, id)) {
+			return nil, fmt.Errorf("cannot move task %s after itself or one of its subtasks", id)
+		}
+	}
+
+	// Take the task out with its subtasks, keeping their order
+	var moved, remaining []*contracts.Task
+	for _, queued := range tasksFile.Tasks {
+		if queued.ID == id || tasksFile.isDescendant(queued, id) {
+			moved = append(moved, queued)
+		} else {
+			remaining = append(remaining, queued)
+		}
+	}
+	tasksFile.Tasks = remaining
+
+	position, err := tasksFile.insertIndex(placement)
 	if err != nil {
 		return nil, err
 	}
+	tasksFile.Tasks = append(remaining[:position], append(moved, remaining[position:]...)...)
 
-	if len(tasksFile.Tasks) == 0 {
+	if err := r.saveTasksFile(queue, tasksFile); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// GetTask retrieves the next pending task from the queue and marks it in progress
+func (r *FileRepository) GetTask(queue string) (*contracts.Task, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tasksFile, err := r.loadTasksFile(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	task := nextPendingTask(tasksFile)
+	if task == nil {
 		return nil, nil
 	}
 
-	// Get the first task (FIFO)
-	task := tasksFile.Tasks[0]
-	tasksFile.Tasks = tasksFile.Tasks[1:]
+	now := time.Now()
+	task.Status = contracts.TaskStatusInProgress
+	task.StartedAt = &now
 
-	if err := r.saveTasksFile(tasksFile); err != nil {
+	if err := r.saveTasksFile(queue, tasksFile); err != nil {
 		return nil, err
 	}
 
 	return task, nil
 }
 
-// Additional methods for testing/debugging purposes (not part of the interface)
+// PeekTask returns the next pending task without changing it
+func (r *FileRepository) PeekTask(queue string) (*contracts.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tasksFile, err := r.loadTasksFile(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	return nextPendingTask(tasksFile), nil
+}
+
+// nextPendingTask returns the task GetTask hands out next
+func nextPendingTask(tasksFile *TasksFile) *contracts.Task {
+	// Get the runnable task with the highest priority, the first one on ties (FIFO)
+	var next *contracts.Task
+	for _, task := range tasksFile.Tasks {
+		if task.Status == contracts.TaskStatusPending && len(task.BlockedBy) == 0 && (next == nil || task.Priority > next.Priority) {
+			next = task
+		}
+	}
+	return next
+}
+
+// FinishTask moves a task into a final status and records an outcome note, cancelling its unfinished subtasks
+func (r *FileRepository) FinishTask(queue string, id string, status contracts.TaskStatus, outcome string) (*contracts.Task, error) {
+	if !status.IsFinished() {
+		return nil, fmt.Errorf("invalid final status: %s", status)
+	}
 
-// ClearAllTasks removes all tasks from the queue
-func (r *FileRepository) ClearAllTasks() error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	tasksFile := &TasksFile{
-		Tasks:      []*contracts.Task{},
-		LastUpdate: time.Now(),
+	tasksFile, err := r.loadTasksFile(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	task := tasksFile.findTask(id)
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+	if task.Status.IsFinished() {
+		return nil, fmt.Errorf("task %s is already %s", id, task.Status)
+	}
+
+	now := time.Now()
+	task.Status = status
+	task.Outcome = outcome
+	task.CompletedAt = &now
+
+	// Subtasks that are still open are no longer needed once their parent is finished
+	for _, other := range tasksFile.Tasks {
+		if !other.Status.IsFinished() && tasksFile.isDescendant(other, id) {
+			other.Status = contracts.TaskStatusCancelled
+			other.Outcome = fmt.Sprintf("Parent task %s was finished as %s", id, status)
+			other.CompletedAt = &now
+		}
+	}
+	tasksFile.rollup(task.ParentID, now)
+	tasksFile.updateBlocked()
+
+	if err := r.saveTasksFile(queue, tasksFile); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// ReleaseTask puts an in-progress task back to pending, so GetTask hands it out again
+func (r *FileRepository) ReleaseTask(queue string, id string) (*contracts.Task, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tasksFile, err := r.loadTasksFile(queue)
+	if err != nil {
+		return nil, err
 	}
 
-	return r.saveTasksFile(tasksFile)
+	task := tasksFile.findTask(id)
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+	if task.Status != contracts.TaskStatusInProgress {
+		return nil, fmt.Errorf("task %s is %s, not %s", id, task.Status, contracts.TaskStatusInProgress)
+	}
+	// A split task is worked on through its subtasks, which are released one by one
+	for _, other := range tasksFile.Tasks {
+		if other.ParentID == id {
+			return nil, fmt.Errorf("task %s has subtasks, release those instead", id)
+		}
+	}
+
+	task.Status = contracts.TaskStatusPending
+	task.StartedAt = nil
+
+	if err := r.saveTasksFile(queue, tasksFile); err != nil {
+		return nil, err
+	}
+
+	return task, nil
 }
 
-// GetTaskCount returns the number of tasks in the queue
-func (r *FileRepository) GetTaskCount() (int, error) {
+// GetTaskCount returns the number of pending tasks in the queue
+func (r *FileRepository) GetTaskCount(queue string) (int, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	tasksFile, err := r.loadTasksFile()
+	tasksFile, err := r.loadTasksFile(queue)
 	if err != nil {
 		return 0, err
 	}
 
-	return len(tasksFile.Tasks), nil
+	count := 0
+	for _, task := range tasksFile.Tasks {
+		if task.Status == contracts.TaskStatusPending {
+			count++
+		}
+	}
+
+	return count, nil
 }
 
-// GetAllTasks returns all tasks in the queue (for testing purposes)
-func (r *FileRepository) GetAllTasks() ([]*contracts.Task, error) {
+// GetAllTasks returns all tasks in the queue, including finished ones
+func (r *FileRepository) GetAllTasks(queue string) ([]*contracts.Task, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	tasksFile, err := r.loadTasksFile()
+	tasksFile, err := r.loadTasksFile(queue)
 	if err != nil {
 		return nil, err
 	}
@@ -193,3 +700,22 @@ func (r *FileRepository) GetAllTasks() ([]*contracts.Task, error) {
 
 	return tasks, nil
 }
+
+// Additional methods for testing/debugging purposes (not part of the interface)
+
+// ClearAllTasks removes all tasks from a queue
+func (r *FileRepository) ClearAllTasks(queue string) error {
+	if err := contracts.ValidateQueueName(queue); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tasksFile := &TasksFile{
+		Tasks:      []*contracts.Task{},
+		LastUpdate: time.Now(),
+	}
+
+	return r.saveTasksFile(queue, tasksFile)
+}
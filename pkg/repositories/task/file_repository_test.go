@@ -2,8 +2,12 @@ package task
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/mstrehse/mcp-brain/pkg/contracts"
 )
 
 func TestFileRepository(t *testing.T) {
@@ -23,7 +27,7 @@ func TestFileRepository(t *testing.T) {
 
 	// Test AddTasks operation
 	contents := []string{"Task 1", "Task 2", "Task 3"}
-	tasks, err := repo.AddTasks(contents)
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents(contents))
 	if err != nil {
 		t.Fatalf("Failed to add tasks: %v", err)
 	}
@@ -40,7 +44,7 @@ func TestFileRepository(t *testing.T) {
 	}
 
 	// Test GetTask operation (FIFO order)
-	task1, err := repo.GetTask()
+	task1, err := repo.GetTask(contracts.DefaultQueue)
 	if err != nil {
 		t.Fatalf("Failed to get task: %v", err)
 	}
@@ -51,7 +55,7 @@ func TestFileRepository(t *testing.T) {
 		t.Errorf("Expected first task content 'Task 1', got '%s'", task1.Content)
 	}
 
-	task2, err := repo.GetTask()
+	task2, err := repo.GetTask(contracts.DefaultQueue)
 	if err != nil {
 		t.Fatalf("Failed to get task: %v", err)
 	}
@@ -63,7 +67,7 @@ func TestFileRepository(t *testing.T) {
 	}
 
 	// Test GetTaskCount
-	count, err := repo.GetTaskCount()
+	count, err := repo.GetTaskCount(contracts.DefaultQueue)
 	if err != nil {
 		t.Fatalf("Failed to get task count: %v", err)
 	}
@@ -72,7 +76,7 @@ func TestFileRepository(t *testing.T) {
 	}
 
 	// Get the last task
-	task3, err := repo.GetTask()
+	task3, err := repo.GetTask(contracts.DefaultQueue)
 	if err != nil {
 		t.Fatalf("Failed to get task: %v", err)
 	}
@@ -84,7 +88,7 @@ func TestFileRepository(t *testing.T) {
 	}
 
 	// Test empty queue
-	emptyTask, err := repo.GetTask()
+	emptyTask, err := repo.GetTask(contracts.DefaultQueue)
 	if err != nil {
 		t.Fatalf("Failed to get task from empty queue: %v", err)
 	}
@@ -109,7 +113,7 @@ func TestFileRepositoryEmptyTaskList(t *testing.T) {
 	defer func() { _ = repo.Close() }()
 
 	// Test adding empty task list
-	tasks, err := repo.AddTasks([]string{})
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{}))
 	if err != nil {
 		t.Fatalf("Failed to add empty task list: %v", err)
 	}
@@ -118,7 +122,7 @@ func TestFileRepositoryEmptyTaskList(t *testing.T) {
 	}
 
 	// Test getting from empty queue
-	task, err := repo.GetTask()
+	task, err := repo.GetTask(contracts.DefaultQueue)
 	if err != nil {
 		t.Fatalf("Failed to get task from empty queue: %v", err)
 	}
@@ -142,7 +146,7 @@ func TestFileRepositoryPersistence(t *testing.T) {
 	}
 
 	contents := []string{"Persistent Task 1", "Persistent Task 2"}
-	_, err = repo1.AddTasks(contents)
+	_, err = repo1.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents(contents))
 	if err != nil {
 		t.Fatalf("Failed to add tasks: %v", err)
 	}
@@ -156,7 +160,7 @@ func TestFileRepositoryPersistence(t *testing.T) {
 	defer func() { _ = repo2.Close() }()
 
 	// Verify tasks persisted
-	count, err := repo2.GetTaskCount()
+	count, err := repo2.GetTaskCount(contracts.DefaultQueue)
 	if err != nil {
 		t.Fatalf("Failed to get task count: %v", err)
 	}
@@ -165,7 +169,7 @@ func TestFileRepositoryPersistence(t *testing.T) {
 	}
 
 	// Get first task
-	task, err := repo2.GetTask()
+	task, err := repo2.GetTask(contracts.DefaultQueue)
 	if err != nil {
 		t.Fatalf("Failed to get task: %v", err)
 	}
@@ -197,7 +201,7 @@ func TestFileRepositoryTimestamps(t *testing.T) {
 
 	// Add tasks
 	contents := []string{"Timestamped Task"}
-	tasks, err := repo.AddTasks(contents)
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents(contents))
 	if err != nil {
 		t.Fatalf("Failed to add tasks: %v", err)
 	}
@@ -212,3 +216,796 @@ func TestFileRepositoryTimestamps(t *testing.T) {
 			task.CreatedAt, beforeTime, afterTime)
 	}
 }
+
+func TestFileRepositoryTaskLifecycle(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Task 1", "Task 2", "Task 3"}))
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+
+	// Every task gets a unique ID and starts as pending
+	seen := make(map[string]bool)
+	for _, task := range tasks {
+		if task.ID == "" || seen[task.ID] {
+			t.Errorf("Expected unique task ID, got %q", task.ID)
+		}
+		seen[task.ID] = true
+		if task.Status != contracts.TaskStatusPending {
+			t.Errorf("Expected pending status, got %s", task.Status)
+		}
+	}
+
+	// Getting a task marks it in progress instead of removing it
+	task, err := repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if task.ID != tasks[0].ID || task.Status != contracts.TaskStatusInProgress || task.StartedAt == nil {
+		t.Errorf("Expected first task in progress, got %+v", task)
+	}
+	all, err := repo.GetAllTasks(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get all tasks: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("Expected task to stay in the queue, got %d tasks", len(all))
+	}
+
+	// Completing records the outcome
+	done, err := repo.FinishTask(contracts.DefaultQueue, task.ID, contracts.TaskStatusDone, "all good")
+	if err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	if done.Status != contracts.TaskStatusDone || done.Outcome != "all good" || done.CompletedAt == nil {
+		t.Errorf("Unexpected completed task: %+v", done)
+	}
+
+	// Finished tasks can't be finished again
+	if _, err := repo.FinishTask(contracts.DefaultQueue, task.ID, contracts.TaskStatusFailed, "oops"); err == nil {
+		t.Error("Expected error when finishing a finished task")
+	}
+
+	// Only final statuses are accepted
+	if _, err := repo.FinishTask(contracts.DefaultQueue, tasks[1].ID, contracts.TaskStatusInProgress, ""); err == nil {
+		t.Error("Expected error for non-final status")
+	}
+
+	// Unknown tasks are reported
+	if _, err := repo.FinishTask(contracts.DefaultQueue, "task-unknown", contracts.TaskStatusDone, ""); err == nil {
+		t.Error("Expected error for unknown task")
+	}
+
+	// The next task is the next pending one
+	next, err := repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if next.ID != tasks[1].ID {
+		t.Errorf("Expected second task, got %s", next.ID)
+	}
+
+	// Pending tasks can be failed without being started
+	failed, err := repo.FinishTask(contracts.DefaultQueue, tasks[2].ID, contracts.TaskStatusFailed, "not possible")
+	if err != nil {
+		t.Fatalf("Failed to fail task: %v", err)
+	}
+	if failed.Status != contracts.TaskStatusFailed {
+		t.Errorf("Expected failed status, got %s", failed.Status)
+	}
+
+	empty, err := repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if empty != nil {
+		t.Errorf("Expected no pending tasks, got %+v", empty)
+	}
+}
+
+func TestFileRepositoryReleaseTask(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Migrate", "Deploy"}))
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+
+	// The agent working on the task crashed, leaving it in progress
+	if _, err := repo.GetTask(contracts.DefaultQueue); err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if _, err := repo.ReleaseTask(contracts.DefaultQueue, tasks[1].ID); err == nil {
+		t.Error("Expected releasing a pending task to fail")
+	}
+
+	released, err := repo.ReleaseTask(contracts.DefaultQueue, tasks[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to release task: %v", err)
+	}
+	if released.Status != contracts.TaskStatusPending || released.StartedAt != nil {
+		t.Errorf("Expected the task to be pending again, got %+v", released)
+	}
+
+	next, err := repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if next == nil || next.ID != tasks[0].ID {
+		t.Errorf("Expected the released task to be handed out again, got %+v", next)
+	}
+
+	// Split tasks are released through their subtasks
+	if _, err := repo.AddSubtasks(contracts.DefaultQueue, tasks[1].ID, contracts.NewTasksFromContents([]string{"Build"})); err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+	if _, err := repo.ReleaseTask(contracts.DefaultQueue, tasks[1].ID); err == nil {
+		t.Error("Expected releasing a task with subtasks to fail")
+	}
+}
+
+func TestFileRepositoryMigratesTasksWithoutIdentity(t *testing.T) {
+	tempDir := t.TempDir()
+
+	legacy := "tasks:\n    - content: Legacy task 1\n      createdat: 2024-01-01T00:00:00Z\n    - content: Legacy task 2\n      createdat: 2024-01-01T00:00:00Z\nlast_update: 2024-01-01T00:00:00Z\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "tasks.yaml"), []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy tasks file: %v", err)
+	}
+
+	repo, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	task, err := repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if task == nil || task.Content != "Legacy task 1" || task.ID == "" {
+		t.Fatalf("Expected legacy task with ID, got %+v", task)
+	}
+
+	// IDs handed out to legacy tasks stay stable and new IDs don't collide
+	added, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"New task"}))
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	all, err := repo.GetAllTasks(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get all tasks: %v", err)
+	}
+	if all[0].ID != task.ID {
+		t.Errorf("Expected stable ID %s, got %s", task.ID, all[0].ID)
+	}
+	for _, existing := range all[:2] {
+		if existing.ID == added[0].ID {
+			t.Errorf("New task ID %s collides with legacy task", added[0].ID)
+		}
+	}
+}
+
+func TestFileRepositoryDependencies(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, []contracts.NewTask{
+		{Content: "Update fixtures", DependsOn: []string{"#2"}},
+		{Content: "Run migrations"},
+		{Content: "Write docs"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	if len(tasks[0].DependsOn) != 1 || tasks[0].DependsOn[0] != tasks[1].ID {
+		t.Fatalf("Expected '#2' to resolve to %s, got %v", tasks[1].ID, tasks[0].DependsOn)
+	}
+	if len(tasks[0].BlockedBy) != 1 {
+		t.Errorf("Expected new task to be blocked, got %v", tasks[0].BlockedBy)
+	}
+
+	// The blocked task is skipped in favour of the next runnable one
+	next, err := repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if next.Content != "Run migrations" {
+		t.Errorf("Expected 'Run migrations', got %q", next.Content)
+	}
+
+	peeked, err := repo.PeekTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to peek task: %v", err)
+	}
+	if peeked.Content != "Write docs" {
+		t.Errorf("Expected 'Write docs' while migrations run, got %q", peeked.Content)
+	}
+
+	// Dependencies that are only in progress still block
+	all, err := repo.GetAllTasks(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get all tasks: %v", err)
+	}
+	if len(all[0].BlockedBy) != 1 {
+		t.Errorf("Expected fixtures to be blocked by running migrations, got %v", all[0].BlockedBy)
+	}
+
+	// Finishing the dependency unblocks the task
+	if _, err := repo.FinishTask(contracts.DefaultQueue, next.ID, contracts.TaskStatusDone, ""); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	next, err = repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if next.Content != "Update fixtures" {
+		t.Errorf("Expected 'Update fixtures' once migrations are done, got %q", next.Content)
+	}
+
+	// New tasks can depend on queued ones by ID
+	later, err := repo.AddTasks(contracts.DefaultQueue, []contracts.NewTask{{Content: "Release", DependsOn: []string{tasks[2].ID, tasks[2].ID}}})
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	if len(later[0].DependsOn) != 1 {
+		t.Errorf("Expected duplicate dependencies to be merged, got %v", later[0].DependsOn)
+	}
+}
+
+func TestFileRepositoryRejectsInvalidDependencies(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tests := map[string][]contracts.NewTask{
+		"unknown ID":         {{Content: "A", DependsOn: []string{"task-404"}}},
+		"reference too high": {{Content: "A", DependsOn: []string{"#2"}}},
+		"invalid reference":  {{Content: "A", DependsOn: []string{"#x"}}},
+		"self dependency":    {{Content: "A", DependsOn: []string{"#1"}}},
+		"cycle": {
+			{Content: "A", DependsOn: []string{"#3"}},
+			{Content: "B", DependsOn: []string{"#1"}},
+			{Content: "C", DependsOn: []string{"#2"}},
+		},
+	}
+
+	for name, newTasks := range tests {
+		if _, err := repo.AddTasks(contracts.DefaultQueue, newTasks); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	// Rejected calls leave the queue untouched
+	all, err := repo.GetAllTasks(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get all tasks: %v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("Expected empty queue, got %d tasks", len(all))
+	}
+}
+
+func TestFileRepositoryRejectsAncestorDependencies(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Refactor storage"}))
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	subtasks, err := repo.AddSubtasks(contracts.DefaultQueue, tasks[0].ID, contracts.NewTasksFromContents([]string{"Extract interface"}))
+	if err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+
+	// The parent only finishes with its subtasks, so they can never wait for it
+	if _, err := repo.AddSubtasks(contracts.DefaultQueue, tasks[0].ID, []contracts.NewTask{{Content: "Cleanup", DependsOn: []string{tasks[0].ID}}}); err == nil {
+		t.Error("Expected a subtask depending on its parent to be rejected")
+	}
+	if _, err := repo.AddSubtasks(contracts.DefaultQueue, subtasks[0].ID, []contracts.NewTask{{Content: "Rename", DependsOn: []string{tasks[0].ID}}}); err == nil {
+		t.Error("Expected a subtask depending on its grandparent to be rejected")
+	}
+
+	all, err := repo.GetAllTasks(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get all tasks: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected rejected subtasks to leave the queue untouched, got %d tasks", len(all))
+	}
+
+	// Other tasks can still wait for the parent
+	if _, err := repo.AddTasks(contracts.DefaultQueue, []contracts.NewTask{{Content: "Release", DependsOn: []string{tasks[0].ID}}}); err != nil {
+		t.Errorf("Expected a task depending on the parent to be accepted, got %v", err)
+	}
+}
+
+func TestFileRepositorySubtasks(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Refactor storage", "Write docs"}))
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	parent := tasks[0]
+
+	subtasks, err := repo.AddSubtasks(contracts.DefaultQueue, parent.ID, []contracts.NewTask{
+		{Content: "Extract interface"},
+		{Content: "Move file code", DependsOn: []string{"#1"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+	if subtasks[0].ParentID != parent.ID || subtasks[1].DependsOn[0] != subtasks[0].ID {
+		t.Fatalf("Unexpected subtasks: %+v, %+v", subtasks[0], subtasks[1])
+	}
+
+	// Subtasks of subtasks stay inside the parent's subtree
+	nested, err := repo.AddSubtasks(contracts.DefaultQueue, subtasks[0].ID, contracts.NewTasksFromContents([]string{"Name methods"}))
+	if err != nil {
+		t.Fatalf("Failed to add nested subtasks: %v", err)
+	}
+
+	all, err := repo.GetAllTasks(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get all tasks: %v", err)
+	}
+	order := []string{}
+	for _, task := range all {
+		order = append(order, task.Content)
+	}
+	expected := []string{"Refactor storage", "Extract interface", "Name methods", "Move file code", "Write docs"}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected order %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+	if all[0].Status != contracts.TaskStatusInProgress {
+		t.Errorf("Expected split task to be in progress, got %s", all[0].Status)
+	}
+
+	// Subtasks run before the rest of the queue
+	for _, content := range []string{"Name methods", "Move file code"} {
+		next, err := repo.GetTask(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		if next.Content != content {
+			t.Fatalf("Expected %q, got %q", content, next.Content)
+		}
+		if _, err := repo.FinishTask(contracts.DefaultQueue, next.ID, contracts.TaskStatusDone, ""); err != nil {
+			t.Fatalf("Failed to complete task: %v", err)
+		}
+		if next.ID == nested[0].ID {
+			// The nested parent finishes with its only subtask, the split task not yet
+			all, _ = repo.GetAllTasks(contracts.DefaultQueue)
+			if all[1].Status != contracts.TaskStatusDone || all[0].Status != contracts.TaskStatusInProgress {
+				t.Fatalf("Unexpected statuses after nested rollup: %s, %s", all[0].Status, all[1].Status)
+			}
+		}
+	}
+
+	all, err = repo.GetAllTasks(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get all tasks: %v", err)
+	}
+	if all[0].Status != contracts.TaskStatusDone || all[0].CompletedAt == nil {
+		t.Errorf("Expected split task to be done once all subtasks are done, got %s", all[0].Status)
+	}
+
+	// Finished tasks can no longer be split
+	if _, err := repo.AddSubtasks(contracts.DefaultQueue, parent.ID, contracts.NewTasksFromContents([]string{"Late"})); err == nil {
+		t.Error("Expected error when splitting a finished task")
+	}
+	if _, err := repo.AddSubtasks(contracts.DefaultQueue, "task-404", contracts.NewTasksFromContents([]string{"Lost"})); err == nil {
+		t.Error("Expected error for unknown parent")
+	}
+}
+
+func TestFileRepositorySplittingBlockedTask(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, []contracts.NewTask{
+		{Content: "Run migrations"},
+		{Content: "Deploy", DependsOn: []string{"#1"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	subtasks, err := repo.AddSubtasks(contracts.DefaultQueue, tasks[1].ID, contracts.NewTasksFromContents([]string{"Build image", "Roll out"}))
+	if err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+
+	for _, subtask := range subtasks {
+		if len(subtask.DependsOn) != 1 || subtask.DependsOn[0] != tasks[0].ID {
+			t.Errorf("Expected %s to inherit the dependency of its parent, got %v", subtask.ID, subtask.DependsOn)
+		}
+	}
+
+	// The subtasks wait for the dependency of the parent like the parent itself
+	next, err := repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if next.ID != tasks[0].ID {
+		t.Fatalf("Expected the dependency first, got %q", next.Content)
+	}
+	if blocked, err := repo.GetTask(contracts.DefaultQueue); err != nil || blocked != nil {
+		t.Errorf("Expected no task while the dependency is in progress, got %v (%v)", blocked, err)
+	}
+
+	if _, err := repo.FinishTask(contracts.DefaultQueue, next.ID, contracts.TaskStatusDone, ""); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	next, err = repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if next.ID != subtasks[0].ID {
+		t.Errorf("Expected the first subtask once the dependency is done, got %q", next.Content)
+	}
+}
+
+func TestFileRepositorySubtaskFailureFailsParent(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Deploy"}))
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	subtasks, err := repo.AddSubtasks(contracts.DefaultQueue, tasks[0].ID, contracts.NewTasksFromContents([]string{"Build", "Upload", "Announce"}))
+	if err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+	nested, err := repo.AddSubtasks(contracts.DefaultQueue, subtasks[1].ID, contracts.NewTasksFromContents([]string{"Upload binary"}))
+	if err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+
+	if _, err := repo.FinishTask(contracts.DefaultQueue, subtasks[0].ID, contracts.TaskStatusDone, ""); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	if _, err := repo.FinishTask(contracts.DefaultQueue, nested[0].ID, contracts.TaskStatusFailed, "Network down"); err != nil {
+		t.Fatalf("Failed to fail task: %v", err)
+	}
+
+	statuses := func() map[string]*contracts.Task {
+		all, err := repo.GetAllTasks(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get all tasks: %v", err)
+		}
+		byID := make(map[string]*contracts.Task)
+		for _, task := range all {
+			byID[task.ID] = task
+		}
+		return byID
+	}
+
+	byID := statuses()
+	if byID[subtasks[1].ID].Status != contracts.TaskStatusFailed || !strings.Contains(byID[subtasks[1].ID].Outcome, nested[0].ID) {
+		t.Errorf("Expected the split subtask to fail with its failed subtask, got %+v", byID[subtasks[1].ID])
+	}
+	if byID[tasks[0].ID].Status != contracts.TaskStatusInProgress {
+		t.Errorf("Expected parent to stay in progress while a subtask is unfinished, got %s", byID[tasks[0].ID].Status)
+	}
+
+	if _, err := repo.FinishTask(contracts.DefaultQueue, subtasks[2].ID, contracts.TaskStatusDone, ""); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	byID = statuses()
+	if parent := byID[tasks[0].ID]; parent.Status != contracts.TaskStatusFailed || parent.Outcome != "Subtasks failed: "+subtasks[1].ID || parent.CompletedAt == nil {
+		t.Errorf("Expected parent to fail once all subtasks are finished, got %+v", parent)
+	}
+}
+
+func TestFileRepositoryFinishingParentCancelsSubtasks(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"Deploy", "Announce"}))
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	subtasks, err := repo.AddSubtasks(contracts.DefaultQueue, tasks[0].ID, contracts.NewTasksFromContents([]string{"Build", "Upload"}))
+	if err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+	nested, err := repo.AddSubtasks(contracts.DefaultQueue, subtasks[1].ID, contracts.NewTasksFromContents([]string{"Upload binary"}))
+	if err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+	if _, err := repo.FinishTask(contracts.DefaultQueue, subtasks[0].ID, contracts.TaskStatusDone, ""); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+
+	if _, err := repo.FinishTask(contracts.DefaultQueue, tasks[0].ID, contracts.TaskStatusDone, "Deployed by hand"); err != nil {
+		t.Fatalf("Failed to complete parent: %v", err)
+	}
+
+	all, err := repo.GetAllTasks(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get all tasks: %v", err)
+	}
+	byID := make(map[string]*contracts.Task)
+	for _, task := range all {
+		byID[task.ID] = task
+	}
+	if byID[subtasks[0].ID].Status != contracts.TaskStatusDone {
+		t.Errorf("Expected the finished subtask to stay done, got %s", byID[subtasks[0].ID].Status)
+	}
+	for _, id := range []string{subtasks[1].ID, nested[0].ID} {
+		if byID[id].Status != contracts.TaskStatusCancelled || byID[id].CompletedAt == nil {
+			t.Errorf("Expected %s to be cancelled with its parent, got %+v", id, byID[id])
+		}
+	}
+
+	next, err := repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if next == nil || next.ID != tasks[1].ID {
+		t.Errorf("Expected the next top-level task instead of a cancelled subtask, got %+v", next)
+	}
+}
+
+func TestFileRepositoryQueues(t *testing.T) {
+	tempDir := t.TempDir()
+
+	legacy := "tasks:\n    - id: task-1\n      content: Legacy task\n      status: pending\nlast_id: 1\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "tasks.yaml"), []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy tasks file: %v", err)
+	}
+
+	repo, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	// The legacy file becomes the default queue
+	if _, err := os.Stat(filepath.Join(tempDir, "tasks", "default.yaml")); err != nil {
+		t.Fatalf("Expected legacy tasks file to be migrated: %v", err)
+	}
+
+	// Queues don't share tasks
+	if _, err := repo.AddTasks("session-a", contracts.NewTasksFromContents([]string{"A1", "A2"})); err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	task, err := repo.GetTask(contracts.DefaultQueue)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if task == nil || task.Content != "Legacy task" {
+		t.Fatalf("Expected legacy task from default queue, got %+v", task)
+	}
+	task, err = repo.GetTask("session-a")
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if task == nil || task.Content != "A1" || task.ID != "task-1" {
+		t.Fatalf("Expected first task of session-a, got %+v", task)
+	}
+
+	queues, err := repo.ListQueues()
+	if err != nil {
+		t.Fatalf("Failed to list queues: %v", err)
+	}
+	if len(queues) != 2 || queues[0].Name != contracts.DefaultQueue || queues[1].Name != "session-a" {
+		t.Fatalf("Expected default and session-a queues, got %+v", queues)
+	}
+	if queues[1].Total != 2 || queues[1].Pending != 1 || queues[1].InProgress != 1 {
+		t.Errorf("Unexpected counts for session-a: %+v", queues[1])
+	}
+
+	// Archived queues start over empty
+	if err := repo.ArchiveQueue("session-a"); err != nil {
+		t.Fatalf("Failed to archive queue: %v", err)
+	}
+	archived, err := os.ReadDir(filepath.Join(tempDir, "tasks", "archive"))
+	if err != nil || len(archived) != 1 {
+		t.Fatalf("Expected one archived queue, got %v (%v)", archived, err)
+	}
+	all, err := repo.GetAllTasks("session-a")
+	if err != nil {
+		t.Fatalf("Failed to get all tasks: %v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("Expected archived queue to start over, got %d tasks", len(all))
+	}
+	if err := repo.ArchiveQueue("session-a"); err == nil {
+		t.Error("Expected error when archiving a missing queue")
+	}
+
+	// Queue names are used as file names
+	for _, name := range []string{"", "../escape", ".hidden", "a/b"} {
+		if _, err := repo.AddTasks(name, contracts.NewTasksFromContents([]string{"X"})); err == nil {
+			t.Errorf("Expected error for queue name %q", name)
+		}
+	}
+}
+
+func TestFileRepositoryPriorities(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	if _, err := repo.AddTasks(contracts.DefaultQueue, []contracts.NewTask{
+		{Content: "Refactor A"},
+		{Content: "Urgent fix", Priority: 2},
+		{Content: "Refactor B"},
+		{Content: "Important fix", Priority: 1},
+		{Content: "Second urgent fix", Priority: 2},
+	}); err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+
+	expected := []string{"Urgent fix", "Second urgent fix", "Important fix", "Refactor A", "Refactor B"}
+	for _, content := range expected {
+		task, err := repo.GetTask(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		if task == nil || task.Content != content {
+			t.Fatalf("Expected %q, got %+v", content, task)
+		}
+	}
+}
+
+func TestFileRepositorySubtasksInheritPriority(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, []contracts.NewTask{
+		{Content: "Urgent fix", Priority: 2},
+		{Content: "Important fix", Priority: 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	subtasks, err := repo.AddSubtasks(contracts.DefaultQueue, tasks[0].ID, []contracts.NewTask{
+		{Content: "Reproduce"},
+		{Content: "Patch", Priority: 3},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+	if subtasks[0].Priority != 2 || subtasks[1].Priority != 3 {
+		t.Errorf("Expected subtask priorities 2 and 3, got %d and %d", subtasks[0].Priority, subtasks[1].Priority)
+	}
+
+	expected := []string{"Patch", "Reproduce", "Important fix"}
+	for _, content := range expected {
+		task, err := repo.GetTask(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		if task == nil || task.Content != content {
+			t.Fatalf("Expected %q, got %+v", content, task)
+		}
+	}
+}
+
+func TestFileRepositoryPlacement(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tasks, err := repo.AddTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"A", "B", "C"}))
+	if err != nil {
+		t.Fatalf("Failed to add tasks: %v", err)
+	}
+	if _, err := repo.AddSubtasks(contracts.DefaultQueue, tasks[0].ID, contracts.NewTasksFromContents([]string{"A.1"})); err != nil {
+		t.Fatalf("Failed to add subtasks: %v", err)
+	}
+
+	contents := func() []string {
+		all, err := repo.GetAllTasks(contracts.DefaultQueue)
+		if err != nil {
+			t.Fatalf("Failed to get all tasks: %v", err)
+		}
+		order := []string{}
+		for _, task := range all {
+			order = append(order, task.Content)
+		}
+		return order
+	}
+	assertOrder := func(t *testing.T, expected ...string) {
+		t.Helper()
+		order := contents()
+		if len(order) != len(expected) {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+		for i := range expected {
+			if order[i] != expected[i] {
+				t.Fatalf("Expected order %v, got %v", expected, order)
+			}
+		}
+	}
+	insert := func(t *testing.T, content string, placement contracts.TaskPlacement) *contracts.Task {
+		t.Helper()
+		inserted, err := repo.InsertTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{content}), placement)
+		if err != nil {
+			t.Fatalf("Failed to insert %q: %v", content, err)
+		}
+		return inserted[0]
+	}
+
+	front := insert(t, "Front", contracts.TaskPlacement{Front: true})
+	assertOrder(t, "Front", "A", "A.1", "B", "C")
+
+	insert(t, "After A", contracts.TaskPlacement{After: tasks[0].ID})
+	assertOrder(t, "Front", "A", "A.1", "After A", "B", "C")
+
+	insert(t, "Third", contracts.TaskPlacement{Position: 3})
+	assertOrder(t, "Front", "A", "Third", "A.1", "After A", "B", "C")
+
+	// Moving a task takes its subtasks along
+	if _, err := repo.MoveTask(contracts.DefaultQueue, tasks[0].ID, contracts.TaskPlacement{After: tasks[2].ID}); err != nil {
+		t.Fatalf("Failed to move task: %v", err)
+	}
+	assertOrder(t, "Front", "Third", "After A", "B", "C", "A", "A.1")
+
+	if _, err := repo.MoveTask(contracts.DefaultQueue, front.ID, contracts.TaskPlacement{Position: 5}); err != nil {
+		t.Fatalf("Failed to move task: %v", err)
+	}
+	assertOrder(t, "Third", "After A", "B", "C", "Front", "A", "A.1")
+
+	invalid := map[string]contracts.TaskPlacement{
+		"several options":   {Front: true, Position: 1},
+		"unknown task":      {After: "task-404"},
+		"position too high": {Position: 99},
+		"negative position": {Position: -1},
+	}
+	for name, placement := range invalid {
+		if _, err := repo.InsertTasks(contracts.DefaultQueue, contracts.NewTasksFromContents([]string{"X"}), placement); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := repo.MoveTask(contracts.DefaultQueue, tasks[0].ID, contracts.TaskPlacement{After: tasks[0].ID}); err == nil {
+		t.Error("Expected error when moving a task after itself")
+	}
+	assertOrder(t, "Third", "After A", "B", "C", "Front", "A", "A.1")
+}
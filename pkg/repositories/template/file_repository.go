@@ -5,16 +5,31 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mstrehse/mcp-brain/pkg/contracts"
 	"gopkg.in/yaml.v3"
 )
 
+// templateIDPattern restricts template IDs to a single path segment that is safe in file names
+var templateIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// validateTemplateID checks that a template ID can be used to store the template
+func validateTemplateID(id string) error {
+	if !templateIDPattern.MatchString(id) {
+		return &contracts.InvalidTemplateIDError{ID: id}
+	}
+	return nil
+}
+
 // FileRepository handles file-based storage for task templates using YAML files
 type FileRepository struct {
 	baseDir string
+	mutex   sync.RWMutex
 }
 
 // NewFileRepository creates a new file-based template repository
@@ -41,26 +56,44 @@ func (r *FileRepository) getTemplateFilePath(id string) string {
 	return filepath.Join(r.baseDir, id+".yaml")
 }
 
+// getHistoryDir returns the directory holding the versions of a template.
+// It is hidden in a subdirectory so ListTemplates doesn't take versions for templates.
+func (r *FileRepository) getHistoryDir(id string) string {
+	return filepath.Join(r.baseDir, ".history", id)
+}
+
+// getVersionFilePath returns the file path for a version of a template
+func (r *FileRepository) getVersionFilePath(id string, version int) string {
+	return filepath.Join(r.getHistoryDir(id), fmt.Sprintf("%d.yaml", version))
+}
+
 // CreateTemplate creates a new task template
 func (r *FileRepository) CreateTemplate(template *contracts.TaskTemplate) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
 	if template.ID == "" {
 		template.ID = generateFileTemplateID(template.Name)
 	}
+	if err := validateTemplateID(template.ID); err != nil {
+		return err
+	}
 
 	// Set timestamps
 	now := time.Now()
 	template.CreatedAt = now
 	template.UpdatedAt = now
+	template.Version = 1
 
 	// Initialize empty slices if nil
 	if template.Parameters == nil {
 		template.Parameters = make(map[string]contracts.Parameter)
 	}
 	if template.Tasks == nil {
-		template.Tasks = []string{}
+		template.Tasks = []contracts.TemplateTask{}
 	}
 	if template.Prerequisites == nil {
-		template.Prerequisites = []string{}
+		template.Prerequisites = []contracts.Prerequisite{}
 	}
 
 	filePath := r.getTemplateFilePath(template.ID)
@@ -75,13 +108,41 @@ func (r *FileRepository) CreateTemplate(template *contracts.TaskTemplate) error
 
 // GetTemplate retrieves a template by ID
 func (r *FileRepository) GetTemplate(id string) (*contracts.TaskTemplate, error) {
-	filePath := r.getTemplateFilePath(id)
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 
-	data, err := os.ReadFile(filePath)
+	return r.getTemplate(id)
+}
+
+// getTemplate retrieves a template by ID. The mutex must be held.
+func (r *FileRepository) getTemplate(id string) (*contracts.TaskTemplate, error) {
+	if err := validateTemplateID(id); err != nil {
+		return nil, err
+	}
+
+	template, err := r.loadTemplate(r.getTemplateFilePath(id))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("template not found: %s", id)
 		}
+		return nil, err
+	}
+
+	// Templates stored before versions existed are their own first version
+	if template.Version == 0 {
+		template.Version = 1
+	}
+
+	return template, nil
+}
+
+// loadTemplate reads a template or template version file
+func (r *FileRepository) loadTemplate(filePath string) (*contracts.TaskTemplate, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to read template file: %w", err)
 	}
 
@@ -95,6 +156,9 @@ func (r *FileRepository) GetTemplate(id string) (*contracts.TaskTemplate, error)
 
 // ListTemplates lists all templates
 func (r *FileRepository) ListTemplates() ([]*contracts.TaskTemplate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
 	files, err := os.ReadDir(r.baseDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read templates directory: %w", err)
@@ -110,7 +174,7 @@ func (r *FileRepository) ListTemplates() ([]*contracts.TaskTemplate, error) {
 		// Extract template ID from filename
 		templateID := strings.TrimSuffix(file.Name(), ".yaml")
 
-		template, err := r.GetTemplate(templateID)
+		template, err := r.getTemplate(templateID)
 		if err != nil {
 			// Skip templates that can't be loaded
 			continue
@@ -122,27 +186,142 @@ func (r *FileRepository) ListTemplates() ([]*contracts.TaskTemplate, error) {
 	return templates, nil
 }
 
-// UpdateTemplate updates an existing template
+// UpdateTemplate stores a template as the next version of an existing one, keeping the previous versions
 func (r *FileRepository) UpdateTemplate(template *contracts.TaskTemplate) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.updateTemplate(template)
+}
+
+// updateTemplate stores a template as the next version of an existing one.
+// The mutex must be held, so concurrent updates can't take the same version.
+func (r *FileRepository) updateTemplate(template *contracts.TaskTemplate) error {
 	if template.ID == "" {
 		return fmt.Errorf("template ID is required for update")
 	}
-
-	filePath := r.getTemplateFilePath(template.ID)
+	if err := validateTemplateID(template.ID); err != nil {
+		return err
+	}
 
 	// Check if template exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("template not found: %s", template.ID)
+	existing, err := r.getTemplate(template.ID)
+	if err != nil {
+		return err
 	}
 
-	// Update timestamp
+	// Templates stored before versions existed have no history yet
+	if _, err := os.Stat(r.getVersionFilePath(existing.ID, existing.Version)); os.IsNotExist(err) {
+		if err := r.saveVersion(existing); err != nil {
+			return err
+		}
+	}
+
+	// Update timestamp and version
 	template.UpdatedAt = time.Now()
+	template.Version = existing.Version + 1
 
 	return r.saveTemplate(template)
 }
 
-// DeleteTemplate deletes a template by ID
+// ListTemplateVersions returns all stored versions of a template, oldest first
+func (r *FileRepository) ListTemplateVersions(id string) ([]*contracts.TemplateVersion, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	current, err := r.getTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(r.getHistoryDir(id))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read template history: %w", err)
+	}
+
+	versions := []*contracts.TemplateVersion{}
+	hasCurrent := false
+	for _, file := range files {
+		version, err := strconv.Atoi(strings.TrimSuffix(file.Name(), ".yaml"))
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".yaml") || err != nil {
+			continue
+		}
+
+		template, err := r.getTemplateVersion(id, version)
+		if err != nil {
+			// Skip versions that can't be loaded
+			continue
+		}
+		hasCurrent = hasCurrent || version == current.Version
+		versions = append(versions, summarizeVersion(template))
+	}
+	if !hasCurrent {
+		versions = append(versions, summarizeVersion(current))
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	return versions, nil
+}
+
+// GetTemplateVersion retrieves a specific version of a template, or the current one for version 0
+func (r *FileRepository) GetTemplateVersion(id string, version int) (*contracts.TaskTemplate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.getTemplateVersion(id, version)
+}
+
+// getTemplateVersion retrieves a specific version of a template. The mutex must be held.
+func (r *FileRepository) getTemplateVersion(id string, version int) (*contracts.TaskTemplate, error) {
+	current, err := r.getTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 || version == current.Version {
+		return current, nil
+	}
+
+	template, err := r.loadTemplate(r.getVersionFilePath(id, version))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("template %s has no version %d", id, version)
+		}
+		return nil, err
+	}
+	template.Version = version
+
+	return template, nil
+}
+
+// RollbackTemplate restores a previous version of a template as its new current version
+func (r *FileRepository) RollbackTemplate(id string, version int) (*contracts.TaskTemplate, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	template, err := r.getTemplateVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.updateTemplate(template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// DeleteTemplate deletes a template and its versions by ID
 func (r *FileRepository) DeleteTemplate(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := validateTemplateID(id); err != nil {
+		return err
+	}
+
 	filePath := r.getTemplateFilePath(id)
 
 	if err := os.Remove(filePath); err != nil {
@@ -152,16 +331,47 @@ func (r *FileRepository) DeleteTemplate(id string) error {
 		return fmt.Errorf("failed to delete template: %w", err)
 	}
 
+	if err := os.RemoveAll(r.getHistoryDir(id)); err != nil {
+		return fmt.Errorf("failed to delete template history: %w", err)
+	}
+
 	return nil
 }
 
-// InstantiateTemplate creates a template instance with resolved parameters
+// InstantiateTemplate creates a template instance with resolved parameters, filling in defaults
 func (r *FileRepository) InstantiateTemplate(templateID string, parameters map[string]string) (*contracts.TemplateInstance, error) {
-	template, err := r.GetTemplate(templateID)
+	return r.InstantiateTemplateVersion(templateID, 0, parameters)
+}
+
+// InstantiateTemplateVersion creates a template instance from a specific version, or the current one for version 0.
+// Included templates are always expanded in their current version.
+func (r *FileRepository) InstantiateTemplateVersion(templateID string, version int, parameters map[string]string) (*contracts.TemplateInstance, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	template, err := r.getTemplateVersion(templateID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters = template.ParametersWithDefaults(parameters)
+
+	resolvedTasks, err := r.expandTemplate(template, parameters, []string{template.ID})
 	if err != nil {
 		return nil, err
 	}
 
+	return &contracts.TemplateInstance{
+		TemplateID: templateID,
+		Version:    template.Version,
+		Parameters: parameters,
+		Tasks:      resolvedTasks,
+	}, nil
+}
+
+// expandTemplate resolves the tasks of a template, expanding conditions, loops and included templates.
+// The chain holds the IDs of the templates currently being expanded, outermost first.
+func (r *FileRepository) expandTemplate(template *contracts.TaskTemplate, parameters map[string]string, chain []string) ([]string, error) {
 	// Validate required parameters
 	for paramName, param := range template.Parameters {
 		if param.Required {
@@ -171,46 +381,207 @@ func (r *FileRepository) InstantiateTemplate(templateID string, parameters map[s
 		}
 	}
 
-	// Resolve template strings
-	resolvedTasks := make([]string, len(template.Tasks))
+	// Parameters without a declaration would end up as raw placeholders in the tasks
+	var undeclared []string
+	for _, name := range template.ReferencedParameters() {
+		if _, declared := template.Parameters[name]; !declared {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		return nil, fmt.Errorf("template uses undeclared parameters: %s", strings.Join(undeclared, ", "))
+	}
+
+	resolvedTasks := []string{}
 	for i, task := range template.Tasks {
-		resolvedTasks[i] = r.resolveTemplate(task, parameters)
+		applies, err := task.Applies(parameters)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		if !applies {
+			continue
+		}
+
+		// Loops repeat the task with ${item} set to each item
+		iterations := []map[string]string{parameters}
+		if task.ForEach != "" {
+			iterations = nil
+			for _, item := range contracts.SplitListValue(parameters[task.ForEach]) {
+				itemParameters := make(map[string]string, len(parameters)+1)
+				for name, value := range parameters {
+					itemParameters[name] = value
+				}
+				itemParameters[contracts.TemplateItemPlaceholder] = item
+				iterations = append(iterations, itemParameters)
+			}
+		}
+
+		for _, iterationParameters := range iterations {
+			if task.Include == "" {
+				resolvedTasks = append(resolvedTasks, r.resolveTemplate(task.Content, iterationParameters))
+				continue
+			}
+
+			included, err := r.includeTemplate(task, iterationParameters, chain)
+			if err != nil {
+				return nil, err
+			}
+			resolvedTasks = append(resolvedTasks, included...)
+		}
 	}
 
-	return &contracts.TemplateInstance{
-		TemplateID: templateID,
-		Parameters: parameters,
-		Tasks:      resolvedTasks,
-	}, nil
+	return resolvedTasks, nil
 }
 
-// saveTemplate saves a template to disk
-func (r *FileRepository) saveTemplate(template *contracts.TaskTemplate) error {
-	filePath := r.getTemplateFilePath(template.ID)
+// includeTemplate expands the template a task includes, with its parameters mapped from the including template
+func (r *FileRepository) includeTemplate(task contracts.TemplateTask, parameters map[string]string, chain []string) ([]string, error) {
+	for i, id := range chain {
+		if id == task.Include {
+			return nil, fmt.Errorf("template include cycle: %s", strings.Join(append(append([]string{}, chain[i:]...), task.Include), " -> "))
+		}
+	}
+	if len(chain) > contracts.MaxTemplateIncludeDepth {
+		return nil, fmt.Errorf("templates are included more than %d levels deep: %s", contracts.MaxTemplateIncludeDepth, strings.Join(chain, " -> "))
+	}
+
+	included, err := r.getTemplate(task.Include)
+	if err != nil {
+		return nil, fmt.Errorf("included template %s: %w", task.Include, err)
+	}
+
+	values := make(map[string]string, len(task.With))
+	for name, value := range task.With {
+		values[name] = r.resolveTemplate(value, parameters)
+	}
+
+	// Mapped values have to fit the included template like values passed to it directly
+	values = included.ParametersWithDefaults(values)
+	if err := included.ValidateParameterValues(values); err != nil {
+		return nil, fmt.Errorf("included template %s: %w", task.Include, err)
+	}
+
+	tasks, err := r.expandTemplate(included, values, append(append([]string{}, chain...), included.ID))
+	if err != nil {
+		return nil, fmt.Errorf("included template %s: %w", task.Include, err)
+	}
+	return tasks, nil
+}
+
+// instantiationsPath returns the file recording when templates were instantiated.
+// It lives in a subdirectory so ListTemplates doesn't take it for a template.
+func (r *FileRepository) instantiationsPath() string {
+	return filepath.Join(r.baseDir, ".state", "instantiations.yaml")
+}
 
+// loadInstantiations reads the last instantiation time of every template
+func (r *FileRepository) loadInstantiations() (map[string]time.Time, error) {
+	instantiations := make(map[string]time.Time)
+
+	data, err := os.ReadFile(r.instantiationsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return instantiations, nil
+		}
+		return nil, fmt.Errorf("failed to read instantiations file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &instantiations); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal instantiations: %w", err)
+	}
+	return instantiations, nil
+}
+
+// RecordInstantiation remembers that tasks were created from a template
+func (r *FileRepository) RecordInstantiation(templateID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	instantiations, err := r.loadInstantiations()
+	if err != nil {
+		return err
+	}
+	instantiations[templateID] = time.Now()
+
+	data, err := yaml.Marshal(instantiations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal instantiations: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.instantiationsPath()), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(r.instantiationsPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write instantiations file: %w", err)
+	}
+	return nil
+}
+
+// LastInstantiation returns when tasks were last created from a template, or nil if never
+func (r *FileRepository) LastInstantiation(templateID string) (*time.Time, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	instantiations, err := r.loadInstantiations()
+	if err != nil {
+		return nil, err
+	}
+	if instantiated, ok := instantiations[templateID]; ok {
+		return &instantiated, nil
+	}
+	return nil, nil
+}
+
+// saveTemplate saves a template to disk as the current version and into its history
+func (r *FileRepository) saveTemplate(template *contracts.TaskTemplate) error {
 	data, err := yaml.Marshal(template)
 	if err != nil {
 		return fmt.Errorf("failed to marshal template: %w", err)
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := r.saveVersion(template); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(r.getTemplateFilePath(template.ID), data, 0644); err != nil {
 		return fmt.Errorf("failed to write template file: %w", err)
 	}
 
 	return nil
 }
 
-// resolveTemplate resolves template parameters in a string
+// saveVersion saves a template into its history under its version
+func (r *FileRepository) saveVersion(template *contracts.TaskTemplate) error {
+	data, err := yaml.Marshal(template)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %w", err)
+	}
+
+	if err := os.MkdirAll(r.getHistoryDir(template.ID), 0755); err != nil {
+		return fmt.Errorf("failed to create template history directory: %w", err)
+	}
+
+	if err := os.WriteFile(r.getVersionFilePath(template.ID, template.Version), data, 0644); err != nil {
+		return fmt.Errorf("failed to write template version file: %w", err)
+	}
+
+	return nil
+}
+
+// summarizeVersion describes a template version for the history
+func summarizeVersion(template *contracts.TaskTemplate) *contracts.TemplateVersion {
+	return &contracts.TemplateVersion{
+		Version:     template.Version,
+		Name:        template.Name,
+		Description: template.Description,
+		Tasks:       len(template.Tasks),
+		UpdatedAt:   template.UpdatedAt,
+	}
+}
+
+// resolveTemplate resolves template parameters in a string.
+// Optional parameters without a value or default are replaced by an empty string.
 func (r *FileRepository) resolveTemplate(template string, parameters map[string]string) string {
-	// Replace ${param} with actual values
-	re := regexp.MustCompile(`\$\{([^}]+)\}`)
-	return re.ReplaceAllStringFunc(template, func(match string) string {
-		paramName := match[2 : len(match)-1] // Remove ${ and }
-		if value, exists := parameters[paramName]; exists {
-			return value
-		}
-		return match // Return original if parameter not found
-	})
+	return contracts.ResolvePlaceholders(template, parameters)
 }
 
 // generateFileTemplateID generates a template ID from a name
@@ -221,6 +592,9 @@ func generateFileTemplateID(name string) string {
 
 	// Remove leading/trailing hyphens
 	id = strings.Trim(id, "-")
+	if id == "" {
+		id = "template"
+	}
 
 	// Add timestamp suffix to ensure uniqueness
 	timestamp := time.Now().Format("20060102-150405")
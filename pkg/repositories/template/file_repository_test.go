@@ -1,7 +1,12 @@
 package template
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -41,13 +46,13 @@ func TestFileRepository(t *testing.T) {
 				Values:      []string{"go", "python", "javascript"},
 			},
 		},
-		Tasks: []string{
+		Tasks: contracts.TemplateTasksFromContents([]string{
 			"Create project structure for ${project_name}",
 			"Initialize ${language} project",
 			"Set up basic configuration",
-		},
-		Prerequisites: []string{
-			"Ensure development environment is set up",
+		}),
+		Prerequisites: []contracts.Prerequisite{
+			{Type: contracts.PrerequisiteTypeNote, Description: "Ensure development environment is set up"},
 		},
 	}
 
@@ -109,19 +114,19 @@ func TestFileRepositoryListTemplates(t *testing.T) {
 			Name:        "Development Template",
 			Description: "For development tasks",
 			Parameters:  map[string]contracts.Parameter{},
-			Tasks:       []string{"Dev task 1", "Dev task 2"},
+			Tasks:       contracts.TemplateTasksFromContents([]string{"Dev task 1", "Dev task 2"}),
 		},
 		{
 			Name:        "Testing Template",
 			Description: "For testing tasks",
 			Parameters:  map[string]contracts.Parameter{},
-			Tasks:       []string{"Test task 1", "Test task 2"},
+			Tasks:       contracts.TemplateTasksFromContents([]string{"Test task 1", "Test task 2"}),
 		},
 		{
 			Name:        "Another Development Template",
 			Description: "Another dev template",
 			Parameters:  map[string]contracts.Parameter{},
-			Tasks:       []string{"Dev task 3"},
+			Tasks:       contracts.TemplateTasksFromContents([]string{"Dev task 3"}),
 		},
 	}
 
@@ -163,7 +168,7 @@ func TestFileRepositoryUpdateTemplate(t *testing.T) {
 		Name:        "Original Template",
 		Description: "Original description",
 		Parameters:  map[string]contracts.Parameter{},
-		Tasks:       []string{"Original task"},
+		Tasks:       contracts.TemplateTasksFromContents([]string{"Original task"}),
 	}
 
 	err = repo.CreateTemplate(template)
@@ -177,7 +182,7 @@ func TestFileRepositoryUpdateTemplate(t *testing.T) {
 	// Update the template
 	template.Name = "Updated Template"
 	template.Description = "Updated description"
-	template.Tasks = []string{"Updated task 1", "Updated task 2"}
+	template.Tasks = contracts.TemplateTasksFromContents([]string{"Updated task 1", "Updated task 2"})
 
 	err = repo.UpdateTemplate(template)
 	if err != nil {
@@ -226,7 +231,7 @@ func TestFileRepositoryDeleteTemplate(t *testing.T) {
 		Name:        "Template to Delete",
 		Description: "This template will be deleted",
 		Parameters:  map[string]contracts.Parameter{},
-		Tasks:       []string{"Temporary task"},
+		Tasks:       contracts.TemplateTasksFromContents([]string{"Temporary task"}),
 	}
 
 	err = repo.CreateTemplate(template)
@@ -253,6 +258,73 @@ func TestFileRepositoryDeleteTemplate(t *testing.T) {
 	}
 }
 
+func TestFileRepositoryRejectsUnsafeTemplateIDs(t *testing.T) {
+	brainDir := t.TempDir()
+	repo, err := NewFileRepository(brainDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	// A sibling of the history directory and a file outside the templates directory
+	// that traversing IDs would reach
+	victimDir := filepath.Join(repo.baseDir, "victim")
+	if err := os.MkdirAll(victimDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(brainDir, "victim.yaml"), []byte("name: Victim\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	template := &contracts.TaskTemplate{Name: "Safe", Tasks: contracts.TemplateTasksFromContents([]string{"Task"})}
+	if err := repo.CreateTemplate(template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	for _, id := range []string{"../victim", "../../escaped", "a/b", ".history", "-flag", `..\victim`} {
+		var idErr *contracts.InvalidTemplateIDError
+		checks := map[string]error{
+			"create": repo.CreateTemplate(&contracts.TaskTemplate{ID: id, Name: "Escaped"}),
+			"update": repo.UpdateTemplate(&contracts.TaskTemplate{ID: id, Name: "Escaped"}),
+			"delete": repo.DeleteTemplate(id),
+		}
+		_, checks["get"] = repo.GetTemplate(id)
+		_, checks["history"] = repo.ListTemplateVersions(id)
+		_, checks["version"] = repo.GetTemplateVersion(id, 1)
+		_, checks["rollback"] = repo.RollbackTemplate(id, 1)
+		_, checks["instantiate"] = repo.InstantiateTemplate(id, nil)
+		for operation, err := range checks {
+			if !errors.As(err, &idErr) {
+				t.Errorf("%s %q: expected InvalidTemplateIDError, got %v", operation, id, err)
+			}
+		}
+	}
+
+	// Including a template by a traversing ID is rejected as well
+	including := &contracts.TaskTemplate{Name: "Including", Tasks: []contracts.TemplateTask{{Include: "../victim"}}}
+	if err := repo.CreateTemplate(including); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	if _, err := repo.InstantiateTemplate(including.ID, nil); err == nil {
+		t.Error("Expected including a traversing ID to fail")
+	}
+
+	if _, err := os.Stat(victimDir); err != nil {
+		t.Errorf("Expected the sibling directory to survive, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(brainDir, "..", "escaped.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file outside the brain directory, got %v", err)
+	}
+}
+
+func TestGenerateFileTemplateID(t *testing.T) {
+	for _, name := range []string{"Deploy Service", "../../etc", "!!!", ""} {
+		if id := generateFileTemplateID(name); validateTemplateID(id) != nil {
+			t.Errorf("Expected a valid ID for name %q, got %q", name, id)
+		}
+	}
+}
+
 func TestFileRepositoryInstantiateTemplate(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "test_template_repo")
@@ -286,11 +358,11 @@ func TestFileRepositoryInstantiateTemplate(t *testing.T) {
 				Values:      []string{"go", "python", "javascript"},
 			},
 		},
-		Tasks: []string{
+		Tasks: contracts.TemplateTasksFromContents([]string{
 			"Create directory structure for ${project_name}",
 			"Initialize ${language} project in ${project_name}",
 			"Set up configuration for ${project_name}",
-		},
+		}),
 	}
 
 	err = repo.CreateTemplate(template)
@@ -340,3 +412,537 @@ func TestFileRepositoryInstantiateTemplate(t *testing.T) {
 		t.Fatal("Expected error for missing required parameter")
 	}
 }
+
+func TestFileRepositoryInstantiateTemplateDefaults(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	template := &contracts.TaskTemplate{
+		Name:        "Release Template",
+		Description: "Releases a project",
+		Parameters: map[string]contracts.Parameter{
+			"version": {Type: "string", Required: true},
+			"channel": {Type: "enum", Default: "stable", Values: []string{"stable", "beta"}},
+			"note":    {Type: "string"},
+		},
+		Tasks: contracts.TemplateTasksFromContents([]string{
+			"Release ${version} to ${channel}",
+			"Announce${note}",
+		}),
+	}
+	if err := repo.CreateTemplate(template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	instance, err := repo.InstantiateTemplate(template.ID, map[string]string{"version": "1.2.0"})
+	if err != nil {
+		t.Fatalf("Failed to instantiate template: %v", err)
+	}
+	if instance.Tasks[0] != "Release 1.2.0 to stable" {
+		t.Errorf("Expected default to be applied, got %q", instance.Tasks[0])
+	}
+	if instance.Tasks[1] != "Announce" {
+		t.Errorf("Expected optional parameter without value to resolve to nothing, got %q", instance.Tasks[1])
+	}
+	if instance.Parameters["channel"] != "stable" {
+		t.Errorf("Expected instance parameters to include defaults, got %v", instance.Parameters)
+	}
+
+	// Templates stored before placeholders were checked may still use undeclared ones
+	template.Tasks = append(template.Tasks, contracts.TemplateTask{Content: "Notify ${team}"})
+	if err := repo.UpdateTemplate(template); err != nil {
+		t.Fatalf("Failed to update template: %v", err)
+	}
+	if _, err := repo.InstantiateTemplate(template.ID, map[string]string{"version": "1.2.0"}); err == nil || !strings.Contains(err.Error(), "team") {
+		t.Errorf("Expected error naming the undeclared placeholder, got %v", err)
+	}
+}
+
+func TestFileRepositoryConditionalAndRepeatedTasks(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	template := &contracts.TaskTemplate{
+		Name:        "Review Template",
+		Description: "Reviews a change",
+		Parameters: map[string]contracts.Parameter{
+			"has_db":   {Type: "boolean", Default: "false"},
+			"services": {Type: "list"},
+			"risk":     {Type: "enum", Default: "low", Values: []string{"low", "high"}},
+		},
+		Tasks: []contracts.TemplateTask{
+			{Content: "Read the diff"},
+			{Content: "Run DB migration check", When: "has_db"},
+			{Content: "Skip DB checks", When: "!has_db"},
+			{Content: "Review ${item}", ForEach: "services"},
+			{Content: "Ask for a second reviewer", When: "risk == high"},
+		},
+	}
+	if err := repo.CreateTemplate(template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		expected   []string
+	}{
+		{
+			name:       "conditions hold",
+			parameters: map[string]string{"has_db": "true", "services": "api, worker", "risk": "high"},
+			expected:   []string{"Read the diff", "Run DB migration check", "Review api", "Review worker", "Ask for a second reviewer"},
+		},
+		{
+			name:       "defaults and empty list",
+			parameters: map[string]string{},
+			expected:   []string{"Read the diff", "Skip DB checks"},
+		},
+	}
+
+	for _, test := range tests {
+		instance, err := repo.InstantiateTemplate(template.ID, test.parameters)
+		if err != nil {
+			t.Fatalf("%s: failed to instantiate template: %v", test.name, err)
+		}
+		if len(instance.Tasks) != len(test.expected) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.expected, instance.Tasks)
+		}
+		for i := range test.expected {
+			if instance.Tasks[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, instance.Tasks)
+				break
+			}
+		}
+	}
+
+	// Plain tasks are still stored as strings, structured ones as mappings
+	data, err := os.ReadFile(filepath.Join(tempDir, "task-templates", template.ID+".yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read template file: %v", err)
+	}
+	if !strings.Contains(string(data), "- Read the diff\n") || !strings.Contains(string(data), "for_each: services") {
+		t.Errorf("Unexpected template file:\n%s", data)
+	}
+}
+
+func TestFileRepositoryReadsStringOnlyTasks(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	legacy := "id: legacy\nname: Legacy\ndescription: Stored before structured tasks\nparameters:\n    target:\n        type: string\n        required: true\ntasks:\n    - Build ${target}\n    - Ship ${target}\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "task-templates", "legacy.yaml"), []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy template: %v", err)
+	}
+
+	instance, err := repo.InstantiateTemplate("legacy", map[string]string{"target": "app"})
+	if err != nil {
+		t.Fatalf("Failed to instantiate legacy template: %v", err)
+	}
+	if len(instance.Tasks) != 2 || instance.Tasks[0] != "Build app" || instance.Tasks[1] != "Ship app" {
+		t.Errorf("Unexpected tasks: %v", instance.Tasks)
+	}
+}
+
+func TestFileRepositoryIncludedTemplates(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := NewFileRepository(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	tail := &contracts.TaskTemplate{
+		ID:          "release-tail",
+		Name:        "Release Tail",
+		Description: "Common final steps",
+		Parameters: map[string]contracts.Parameter{
+			"component": {Type: "string", Required: true},
+			"changelog": {Type: "string", Default: "CHANGELOG.md"},
+		},
+		Tasks: contracts.TemplateTasksFromContents([]string{"Run tests of ${component}", "Update ${changelog}"}),
+	}
+	feature := &contracts.TaskTemplate{
+		ID:          "feature",
+		Name:        "Feature",
+		Description: "Builds a feature",
+		Parameters: map[string]contracts.Parameter{
+			"name":     {Type: "string", Required: true},
+			"services": {Type: "list"},
+		},
+		Tasks: []contracts.TemplateTask{
+			{Content: "Implement ${name}"},
+			{Include: "release-tail", With: map[string]string{"component": "${item} for ${name}"}, ForEach: "services"},
+		},
+	}
+	for _, template := range []*contracts.TaskTemplate{tail, feature} {
+		if err := repo.CreateTemplate(template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	instance, err := repo.InstantiateTemplate("feature", map[string]string{"name": "login", "services": "api, web"})
+	if err != nil {
+		t.Fatalf("Failed to instantiate template: %v", err)
+	}
+	expected := []string{
+		"Implement login",
+		"Run tests of api for login", "Update CHANGELOG.md",
+		"Run tests of web for login", "Update CHANGELOG.md",
+	}
+	if len(instance.Tasks) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, instance.Tasks)
+	}
+	for i := range expected {
+		if instance.Tasks[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, instance.Tasks)
+		}
+	}
+
+	// Cycles are detected, even if the stored files were edited by hand
+	tail.Tasks = append(tail.Tasks, contracts.TemplateTask{Include: "feature", With: map[string]string{"name": "${component}"}})
+	if err := repo.UpdateTemplate(tail); err != nil {
+		t.Fatalf("Failed to update template: %v", err)
+	}
+	if _, err := repo.InstantiateTemplate("feature", map[string]string{"name": "login", "services": "api"}); err == nil || !strings.Contains(err.Error(), "feature -> release-tail -> feature") {
+		t.Errorf("Expected include cycle error, got %v", err)
+	}
+
+	// Chains of includes are limited in depth
+	for i := 0; i <= contracts.MaxTemplateIncludeDepth+1; i++ {
+		level := &contracts.TaskTemplate{
+			ID:          fmt.Sprintf("level-%d", i),
+			Name:        "Level",
+			Description: "Nested template",
+			Tasks:       []contracts.TemplateTask{{Include: fmt.Sprintf("level-%d", i+1)}},
+		}
+		if err := repo.CreateTemplate(level); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+	if _, err := repo.InstantiateTemplate("level-0", nil); err == nil || !strings.Contains(err.Error(), "levels deep") {
+		t.Errorf("Expected depth limit error, got %v", err)
+	}
+}
+
+func TestFileRepositoryValidatesIncludedParameters(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	minReplicas := 1.0
+	deploy := &contracts.TaskTemplate{
+		ID:   "deploy",
+		Name: "Deploy",
+		Parameters: map[string]contracts.Parameter{
+			"replicas":    {Type: contracts.ParameterTypeNumber, Min: &minReplicas},
+			"canary":      {Type: contracts.ParameterTypeBoolean},
+			"environment": {Type: contracts.ParameterTypeEnum, Values: []string{"staging", "production"}},
+			"regions":     {Type: contracts.ParameterTypeList, Values: []string{"eu", "us"}},
+		},
+		Tasks: contracts.TemplateTasksFromContents([]string{"Deploy ${replicas} replicas to ${environment} in ${regions} (canary: ${canary})"}),
+	}
+	release := &contracts.TaskTemplate{
+		ID:   "release",
+		Name: "Release",
+		Parameters: map[string]contracts.Parameter{
+			"replicas":    {Type: contracts.ParameterTypeString},
+			"canary":      {Type: contracts.ParameterTypeString},
+			"environment": {Type: contracts.ParameterTypeString},
+			"regions":     {Type: contracts.ParameterTypeString},
+		},
+		Tasks: []contracts.TemplateTask{{Include: "deploy", With: map[string]string{
+			"replicas":    "${replicas}",
+			"canary":      "${canary}",
+			"environment": "${environment}",
+			"regions":     "${regions}",
+		}}},
+	}
+	for _, template := range []*contracts.TaskTemplate{deploy, release} {
+		if err := repo.CreateTemplate(template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	if _, err := repo.InstantiateTemplate("release", map[string]string{"replicas": "3", "canary": "true", "environment": "staging", "regions": "eu, us"}); err != nil {
+		t.Fatalf("Expected valid values to be passed on, got %v", err)
+	}
+
+	// Values that deploy rejects when instantiated directly are rejected when included as well
+	invalid := map[string]string{"replicas": "0", "canary": "maybe", "environment": "moon", "regions": "eu, mars"}
+	_, err = repo.InstantiateTemplate("release", invalid)
+	if err == nil {
+		t.Fatal("Expected invalid included values to be rejected")
+	}
+	for name := range invalid {
+		if !strings.Contains(err.Error(), "'"+name+"'") {
+			t.Errorf("Expected error to mention '%s', got: %v", name, err)
+		}
+	}
+	if err := deploy.ValidateParameterValues(invalid); err == nil {
+		t.Error("Expected direct validation to reject the same values")
+	}
+}
+
+func TestFileRepositoryInstantiations(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	instantiated, err := repo.LastInstantiation("setup")
+	if err != nil {
+		t.Fatalf("Failed to get last instantiation: %v", err)
+	}
+	if instantiated != nil {
+		t.Errorf("Expected no instantiation yet, got %v", instantiated)
+	}
+
+	if err := repo.RecordInstantiation("setup"); err != nil {
+		t.Fatalf("Failed to record instantiation: %v", err)
+	}
+	instantiated, err = repo.LastInstantiation("setup")
+	if err != nil {
+		t.Fatalf("Failed to get last instantiation: %v", err)
+	}
+	if instantiated == nil || time.Since(*instantiated) > time.Minute {
+		t.Errorf("Expected a recent instantiation, got %v", instantiated)
+	}
+
+	// The record is not listed as a template
+	templates, err := repo.ListTemplates()
+	if err != nil {
+		t.Fatalf("Failed to list templates: %v", err)
+	}
+	if len(templates) != 0 {
+		t.Errorf("Expected no templates, got %d", len(templates))
+	}
+}
+
+func TestFileRepositoryStructuredPrerequisites(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	// Plain string prerequisites stored before checks existed are read as notes
+	legacy := "id: legacy\nname: Legacy\ndescription: Old template\ntasks:\n  - Do it\nprerequisites:\n  - Ask the team\n"
+	if err := os.WriteFile(filepath.Join(repo.baseDir, "legacy.yaml"), []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	template, err := repo.GetTemplate("legacy")
+	if err != nil {
+		t.Fatalf("Failed to get template: %v", err)
+	}
+	if len(template.Prerequisites) != 1 || template.Prerequisites[0].Type != contracts.PrerequisiteTypeNote || template.Prerequisites[0].Description != "Ask the team" {
+		t.Errorf("Expected a note prerequisite, got %+v", template.Prerequisites)
+	}
+
+	// Checks round-trip through YAML
+	template.Prerequisites = append(template.Prerequisites, contracts.Prerequisite{Type: contracts.PrerequisiteTypeCommand, Command: "docker"})
+	if err := repo.UpdateTemplate(template); err != nil {
+		t.Fatalf("Failed to update template: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(repo.baseDir, "legacy.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	if !strings.Contains(string(data), "- Ask the team") {
+		t.Errorf("Expected notes to stay plain strings, got:\n%s", data)
+	}
+	template, err = repo.GetTemplate("legacy")
+	if err != nil {
+		t.Fatalf("Failed to get template: %v", err)
+	}
+	if len(template.Prerequisites) != 2 || template.Prerequisites[1].Command != "docker" {
+		t.Errorf("Expected the command prerequisite to be kept, got %+v", template.Prerequisites)
+	}
+}
+
+func TestFileRepositoryTemplateVersions(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	template := &contracts.TaskTemplate{
+		ID:          "review",
+		Name:        "Review",
+		Description: "Reviews a change",
+		Tasks:       contracts.TemplateTasksFromContents([]string{"Read the diff"}),
+	}
+	if err := repo.CreateTemplate(template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	if template.Version != 1 {
+		t.Errorf("Expected version 1, got %d", template.Version)
+	}
+
+	updated := &contracts.TaskTemplate{
+		ID:          "review",
+		Name:        "Review",
+		Description: "Reviews a change badly",
+		Tasks:       contracts.TemplateTasksFromContents([]string{"Approve"}),
+	}
+	if err := repo.UpdateTemplate(updated); err != nil {
+		t.Fatalf("Failed to update template: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("Expected version 2, got %d", updated.Version)
+	}
+
+	// Old versions can still be read and instantiated
+	old, err := repo.GetTemplateVersion("review", 1)
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	if old.Tasks[0].Content != "Read the diff" {
+		t.Errorf("Expected the original task, got %v", old.Tasks)
+	}
+	instance, err := repo.InstantiateTemplateVersion("review", 1, nil)
+	if err != nil {
+		t.Fatalf("Failed to instantiate version: %v", err)
+	}
+	if instance.Version != 1 || instance.Tasks[0] != "Read the diff" {
+		t.Errorf("Expected tasks of version 1, got version %d with %v", instance.Version, instance.Tasks)
+	}
+	if _, err := repo.GetTemplateVersion("review", 7); err == nil || !strings.Contains(err.Error(), "no version 7") {
+		t.Errorf("Expected missing version error, got %v", err)
+	}
+
+	// Rolling back stores the old content as a new version
+	restored, err := repo.RollbackTemplate("review", 1)
+	if err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+	if restored.Version != 3 {
+		t.Errorf("Expected version 3, got %d", restored.Version)
+	}
+	current, err := repo.GetTemplate("review")
+	if err != nil {
+		t.Fatalf("Failed to get template: %v", err)
+	}
+	if current.Version != 3 || current.Tasks[0].Content != "Read the diff" {
+		t.Errorf("Expected restored version 3, got version %d with %v", current.Version, current.Tasks)
+	}
+
+	versions, err := repo.ListTemplateVersions("review")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	if len(versions) != 3 || versions[0].Version != 1 || versions[1].Description != "Reviews a change badly" || versions[2].Version != 3 {
+		t.Errorf("Expected versions 1 to 3, got %+v", versions)
+	}
+
+	// History is not listed as templates and is removed with the template
+	templates, err := repo.ListTemplates()
+	if err != nil {
+		t.Fatalf("Failed to list templates: %v", err)
+	}
+	if len(templates) != 1 {
+		t.Errorf("Expected 1 template, got %d", len(templates))
+	}
+	if err := repo.DeleteTemplate("review"); err != nil {
+		t.Fatalf("Failed to delete template: %v", err)
+	}
+	if _, err := os.Stat(repo.getHistoryDir("review")); !os.IsNotExist(err) {
+		t.Errorf("Expected history to be deleted, got %v", err)
+	}
+}
+
+func TestFileRepositoryConcurrentUpdates(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	template := &contracts.TaskTemplate{Name: "Release", Tasks: contracts.TemplateTasksFromContents([]string{"Tag"})}
+	if err := repo.CreateTemplate(template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	const updates = 10
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			update := &contracts.TaskTemplate{ID: template.ID, Name: fmt.Sprintf("Release %d", i), Tasks: template.Tasks}
+			if err := repo.UpdateTemplate(update); err != nil {
+				t.Errorf("Failed to update template: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Every update gets a version of its own, none overwrites another
+	versions, err := repo.ListTemplateVersions(template.ID)
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	if len(versions) != updates+1 || versions[len(versions)-1].Version != updates+1 {
+		t.Fatalf("Expected %d versions, got %d", updates+1, len(versions))
+	}
+	names := make(map[string]bool)
+	for _, version := range versions[1:] {
+		names[version.Name] = true
+	}
+	if len(names) != updates {
+		t.Errorf("Expected every update in the history, got %v", names)
+	}
+}
+
+func TestFileRepositoryVersionsOfLegacyTemplates(t *testing.T) {
+	repo, err := NewFileRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	// Templates stored before versions existed have no version and no history
+	legacy := "id: legacy\nname: Legacy\ndescription: Old template\ntasks:\n  - Do it\n"
+	if err := os.WriteFile(filepath.Join(repo.baseDir, "legacy.yaml"), []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	versions, err := repo.ListTemplateVersions("legacy")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Version != 1 {
+		t.Errorf("Expected the template as version 1, got %+v", versions)
+	}
+
+	template, err := repo.GetTemplate("legacy")
+	if err != nil {
+		t.Fatalf("Failed to get template: %v", err)
+	}
+	template.Tasks = contracts.TemplateTasksFromContents([]string{"Do it better"})
+	if err := repo.UpdateTemplate(template); err != nil {
+		t.Fatalf("Failed to update template: %v", err)
+	}
+
+	old, err := repo.GetTemplateVersion("legacy", 1)
+	if err != nil {
+		t.Fatalf("Failed to get version 1: %v", err)
+	}
+	if old.Tasks[0].Content != "Do it" {
+		t.Errorf("Expected the original content to be kept, got %v", old.Tasks)
+	}
+}
@@ -0,0 +1,98 @@
+package transport
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Transports the MCP server can be served over
+const (
+	// Stdio serves a single client over standard input and output
+	Stdio = "stdio"
+
+	// HTTP serves clients over streamable HTTP at /mcp
+	HTTP = "http"
+
+	// SSE serves clients over server-sent events at /sse, with messages posted to /message
+	SSE = "sse"
+)
+
+// DefaultListenAddr is the address the HTTP and SSE transports listen on by default
+const DefaultListenAddr = "127.0.0.1:8080"
+
+// NewHandler returns the HTTP handler serving the MCP server over the given network transport.
+// Requests need the bearer token in their Authorization header, unless the token is empty.
+func NewHandler(s *server.MCPServer, transport string, token string) (http.Handler, error) {
+	var handler http.Handler
+	switch transport {
+	case HTTP:
+		mux := http.NewServeMux()
+		mux.Handle("/mcp", server.NewStreamableHTTPServer(s))
+		handler = mux
+	case SSE:
+		// Relative message endpoints keep working behind proxies and port forwards
+		handler = server.NewSSEServer(s, server.WithUseFullURLForMessageEndpoint(false))
+	default:
+		return nil, fmt.Errorf("unknown network transport '%s', use %s or %s", transport, HTTP, SSE)
+	}
+
+	if token == "" {
+		return handler, nil
+	}
+	return RequireBearerToken(token, handler), nil
+}
+
+// RequireBearerToken rejects requests that don't carry the token as bearer token
+func RequireBearerToken(token string, next http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-brain"`)
+			http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Serve serves the MCP server over the given transport until it fails.
+// Network transports without a token are only allowed on loopback addresses.
+func Serve(s *server.MCPServer, transport string, addr string, token string) error {
+	if transport == Stdio {
+		return server.ServeStdio(s)
+	}
+
+	if token == "" {
+		if !IsLoopback(addr) {
+			return fmt.Errorf("an auth token is required to listen on %s, which is reachable from other machines", addr)
+		}
+		log.Printf("Serving without authentication on %s\n", addr)
+	}
+
+	handler, err := NewHandler(s, transport, token)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Serving MCP over %s on %s\n", transport, addr)
+	return http.ListenAndServe(addr, handler)
+}
+
+// IsLoopback reports whether a listen address only accepts connections from the local machine
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
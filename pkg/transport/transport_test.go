@@ -0,0 +1,160 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	mcptransport "github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// newTestServer returns an MCP server with a single echo tool
+func newTestServer() *server.MCPServer {
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTool(mcp.NewTool("echo", mcp.WithString("text")), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(request.GetString("text", "")), nil
+	})
+	return s
+}
+
+// callEcho initializes the client and calls the echo tool
+func callEcho(t *testing.T, c *client.Client) {
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list tools: %v", err)
+	}
+	if len(tools.Tools) != 1 || tools.Tools[0].Name != "echo" {
+		t.Fatalf("Expected the echo tool, got %+v", tools.Tools)
+	}
+
+	callRequest := mcp.CallToolRequest{}
+	callRequest.Params.Name = "echo"
+	callRequest.Params.Arguments = map[string]interface{}{"text": "hello brain"}
+	result, err := c.CallTool(ctx, callRequest)
+	if err != nil {
+		t.Fatalf("Failed to call tool: %v", err)
+	}
+	if text, ok := mcp.AsTextContent(result.Content[0]); !ok || text.Text != "hello brain" {
+		t.Errorf("Expected echoed text, got %+v", result.Content)
+	}
+}
+
+func TestStreamableHTTPTransport(t *testing.T) {
+	handler, err := NewHandler(newTestServer(), HTTP, "secret")
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	t.Run("clients with the token can call tools", func(t *testing.T) {
+		c, err := client.NewStreamableHttpClient(httpServer.URL+"/mcp", mcptransport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer secret"}))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		callEcho(t, c)
+	})
+
+	t.Run("clients without the token are rejected", func(t *testing.T) {
+		for _, header := range []string{"", "Bearer wrong", "secret"} {
+			request, err := http.NewRequest(http.MethodPost, httpServer.URL+"/mcp", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			if header != "" {
+				request.Header.Set("Authorization", header)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			_ = response.Body.Close()
+			if response.StatusCode != http.StatusUnauthorized {
+				t.Errorf("Expected 401 for Authorization %q, got %d", header, response.StatusCode)
+			}
+		}
+	})
+}
+
+func TestSSETransport(t *testing.T) {
+	handler, err := NewHandler(newTestServer(), SSE, "secret")
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	t.Run("clients with the token can call tools", func(t *testing.T) {
+		c, err := client.NewSSEMCPClient(httpServer.URL+"/sse", client.WithHeaders(map[string]string{"Authorization": "Bearer secret"}))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		callEcho(t, c)
+	})
+
+	t.Run("clients without the token are rejected", func(t *testing.T) {
+		c, err := client.NewSSEMCPClient(httpServer.URL + "/sse")
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := c.Start(context.Background()); err == nil {
+			_ = c.Close()
+			t.Error("Expected the connection to be rejected")
+		}
+	})
+}
+
+func TestNewHandlerWithoutToken(t *testing.T) {
+	if _, err := NewHandler(newTestServer(), Stdio, ""); err == nil {
+		t.Error("Expected stdio to be rejected as network transport")
+	}
+
+	handler, err := NewHandler(newTestServer(), HTTP, "")
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	c, err := client.NewStreamableHttpClient(httpServer.URL + "/mcp")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	callEcho(t, c)
+}
+
+func TestServeRequiresTokenOffLoopback(t *testing.T) {
+	if err := Serve(newTestServer(), HTTP, "0.0.0.0:0", ""); err == nil {
+		t.Error("Expected serving without a token on all interfaces to be refused")
+	}
+
+	for addr, expected := range map[string]bool{
+		"127.0.0.1:8080": true,
+		"localhost:8080": true,
+		"[::1]:8080":     true,
+		"0.0.0.0:8080":   false,
+		":8080":          false,
+		"10.0.0.5:8080":  false,
+	} {
+		if IsLoopback(addr) != expected {
+			t.Errorf("Expected IsLoopback(%q) to be %v", addr, expected)
+		}
+	}
+}